@@ -1,6 +1,7 @@
 package ast
 
 import (
+	"context"
 	"fmt"
 	"sort"
 )
@@ -21,21 +22,35 @@ type Node struct {
 	OldVal   any
 	NewVal   any
 	Children []Node
+	// Warnings are notes about the inputs, such as a repeated key, that
+	// formatters show next to the node where they can (see
+	// formatters.ShowsWarnings).
+	Warnings []string
 }
 
 type JsonNode struct {
-	Key      string     `json:"key"`
-	Type     string     `json:"type"`
-	OldValue any        `json:"oldValue,omitempty"`
-	NewValue any        `json:"newValue,omitempty"`
-	Children []JsonNode `json:"children,omitempty"`
+	Key      string     `json:"key" yaml:"key"`
+	Type     string     `json:"type" yaml:"type"`
+	OldValue any        `json:"oldValue,omitempty" yaml:"oldValue,omitempty"`
+	NewValue any        `json:"newValue,omitempty" yaml:"newValue,omitempty"`
+	Children []JsonNode `json:"children,omitempty" yaml:"children,omitempty"`
+	Warnings []string   `json:"warnings,omitempty" yaml:"warnings,omitempty"`
 }
 
 func BuildDiff(a, b map[string]any) []Node {
+	nodes, _ := BuildDiffContext(context.Background(), a, b)
+	return nodes
+}
+
+// BuildDiffContext is BuildDiff that gives up with ctx.Err() once ctx is done.
+func BuildDiffContext(ctx context.Context, a, b map[string]any) ([]Node, error) {
 	keys := unionKeys(a, b)
 	sort.Strings(keys)
 	out := make([]Node, 0, len(keys))
 	for _, k := range keys {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		v1, ok1 := a[k]
 		v2, ok2 := b[k]
 		switch {
@@ -46,18 +61,22 @@ func BuildDiff(a, b map[string]any) []Node {
 		default:
 			if m1, ok := v1.(map[string]any); ok {
 				if m2, ok2 := v2.(map[string]any); ok2 {
-					out = append(out, Node{Key: k, Action: Nested, Children: BuildDiff(m1, m2)})
+					children, err := BuildDiffContext(ctx, m1, m2)
+					if err != nil {
+						return nil, err
+					}
+					out = append(out, Node{Key: k, Action: Nested, Children: children})
 					continue
 				}
 			}
-			if equals(v1, v2) {
+			if Equal(v1, v2) {
 				out = append(out, Node{Key: k, Action: Unchanged, OldVal: v1})
 			} else {
 				out = append(out, Node{Key: k, Action: Updated, OldVal: v1, NewVal: v2})
 			}
 		}
 	}
-	return out
+	return out, nil
 }
 
 func unionKeys(a, b map[string]any) []string {
@@ -75,6 +94,142 @@ func unionKeys(a, b map[string]any) []string {
 	return out
 }
 
-func equals(a, b any) bool {
+// Equal reports whether two parsed values are the same leaf for BuildDiff;
+// numbers compare by printed value, so 1 and 1.0 are equal.
+func Equal(a, b any) bool {
 	return fmt.Sprintf("%#v", a) == fmt.Sprintf("%#v", b)
 }
+
+type Summary struct {
+	Added     int              `json:"added"`
+	Removed   int              `json:"removed"`
+	Updated   int              `json:"updated"`
+	Unchanged int              `json:"unchanged"`
+	MaxDepth  int              `json:"maxDepth"`
+	Sections  []SectionSummary `json:"sections"`
+}
+
+type SectionSummary struct {
+	Key     string `json:"key"`
+	Added   int    `json:"added"`
+	Removed int    `json:"removed"`
+	Updated int    `json:"updated"`
+}
+
+func (s SectionSummary) Changes() int {
+	return s.Added + s.Removed + s.Updated
+}
+
+// Summarize counts leaf changes in the tree, overall and per top-level key.
+// MaxDepth is the depth of the deepest changed key (top level is 1); only
+// sections with changes are listed.
+func Summarize(nodes []Node) Summary {
+	var s Summary
+	for _, n := range nodes {
+		var sec SectionSummary
+		sec.Key = n.Key
+		summarize(n, 1, &s, &sec)
+		if sec.Changes() > 0 {
+			s.Sections = append(s.Sections, sec)
+		}
+	}
+	return s
+}
+
+func summarize(n Node, depth int, s *Summary, sec *SectionSummary) {
+	switch n.Action {
+	case Nested:
+		for _, c := range n.Children {
+			summarize(c, depth+1, s, sec)
+		}
+		return
+	case Unchanged:
+		s.Unchanged++
+		return
+	case Added:
+		s.Added++
+		sec.Added++
+	case Removed:
+		s.Removed++
+		sec.Removed++
+	case Updated:
+		s.Updated++
+		sec.Updated++
+	}
+	s.MaxDepth = max(s.MaxDepth, depth)
+}
+
+// HasChanges reports whether any node in the tree was added, removed or updated.
+func HasChanges(nodes []Node) bool {
+	for _, n := range nodes {
+		switch n.Action {
+		case Added, Removed, Updated:
+			return true
+		case Nested:
+			if HasChanges(n.Children) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Warning is a note about the inputs for the node at Path, such as a repeated
+// key found while parsing.
+type Warning struct {
+	Path []string
+	Text string
+}
+
+// Annotate adds each warning to the node at its path, or to the deepest node
+// on the way there when the path leads into a value that is not diffed key by
+// key.
+func Annotate(nodes []Node, warnings []Warning) {
+	for _, w := range warnings {
+		annotate(nodes, w.Path, w.Text)
+	}
+}
+
+func annotate(nodes []Node, path []string, text string) {
+	for i := range nodes {
+		n := &nodes[i]
+		if n.Key != path[0] {
+			continue
+		}
+		if len(path) > 1 && n.Action == Nested && hasKey(n.Children, path[1]) {
+			annotate(n.Children, path[1:], text)
+			return
+		}
+		n.Warnings = append(n.Warnings, text)
+		return
+	}
+}
+
+func hasKey(nodes []Node, key string) bool {
+	for _, n := range nodes {
+		if n.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// CollectWarnings lists the warnings in the tree in order, each prefixed with
+// the dotted path of its node, for output that cannot show them in place.
+func CollectWarnings(nodes []Node) []string {
+	return collectWarnings(nil, nodes, "")
+}
+
+func collectWarnings(out []string, nodes []Node, parentPath string) []string {
+	for _, n := range nodes {
+		path := n.Key
+		if parentPath != "" {
+			path = parentPath + "." + n.Key
+		}
+		for _, w := range n.Warnings {
+			out = append(out, path+": "+w)
+		}
+		out = collectWarnings(out, n.Children, path)
+	}
+	return out
+}
@@ -1,6 +1,9 @@
 package ast
 
 import (
+	"context"
+	"errors"
+	"reflect"
 	"testing"
 )
 
@@ -34,3 +37,82 @@ func TestBuildDiff_Nested(t *testing.T) {
 		t.Fatalf("unexpected child kinds: %#v", child)
 	}
 }
+
+func TestSummarize(t *testing.T) {
+	nodes := []Node{
+		{Key: "a", Action: Unchanged, OldVal: 1},
+		{Key: "b", Action: Nested, Children: []Node{
+			{Key: "c", Action: Added, NewVal: 1},
+			{Key: "d", Action: Nested, Children: []Node{
+				{Key: "e", Action: Updated, OldVal: 1, NewVal: 2},
+				{Key: "f", Action: Unchanged, OldVal: 1},
+			}},
+		}},
+		{Key: "g", Action: Removed, OldVal: 1},
+		{Key: "h", Action: Nested, Children: []Node{
+			{Key: "i", Action: Unchanged, OldVal: 1},
+		}},
+	}
+
+	got := Summarize(nodes)
+
+	if got.Added != 1 || got.Removed != 1 || got.Updated != 1 || got.Unchanged != 3 {
+		t.Fatalf("unexpected counts: %#v", got)
+	}
+	if got.MaxDepth != 3 {
+		t.Fatalf("MaxDepth = %d, want 3", got.MaxDepth)
+	}
+	want := []SectionSummary{
+		{Key: "b", Added: 1, Updated: 1},
+		{Key: "g", Removed: 1},
+	}
+	if len(got.Sections) != len(want) || got.Sections[0] != want[0] || got.Sections[1] != want[1] {
+		t.Fatalf("Sections = %#v, want %#v", got.Sections, want)
+	}
+}
+
+func TestHasChanges(t *testing.T) {
+	same := []Node{
+		{Key: "a", Action: Unchanged, OldVal: 1},
+		{Key: "b", Action: Nested, Children: []Node{{Key: "c", Action: Unchanged, OldVal: 2}}},
+	}
+	if HasChanges(same) {
+		t.Fatalf("HasChanges(unchanged tree) = true, want false")
+	}
+	if HasChanges(nil) {
+		t.Fatalf("HasChanges(nil) = true, want false")
+	}
+
+	deep := []Node{
+		{Key: "b", Action: Nested, Children: []Node{{Key: "c", Action: Removed, OldVal: 2}}},
+	}
+	if !HasChanges(deep) {
+		t.Fatalf("HasChanges(nested removal) = false, want true")
+	}
+}
+
+func TestCollectWarnings(t *testing.T) {
+	nodes := []Node{
+		{Key: "a", Action: Unchanged, OldVal: 1, Warnings: []string{"first"}},
+		{Key: "b", Action: Nested, Warnings: []string{"second"}, Children: []Node{
+			{Key: "c", Action: Updated, OldVal: 1, NewVal: 2, Warnings: []string{"third", "fourth"}},
+		}},
+	}
+	want := []string{"a: first", "b: second", "b.c: third", "b.c: fourth"}
+	if got := CollectWarnings(nodes); !reflect.DeepEqual(got, want) {
+		t.Fatalf("CollectWarnings() = %q, want %q", got, want)
+	}
+	if got := CollectWarnings([]Node{{Key: "a", Action: Added, NewVal: 1}}); got != nil {
+		t.Fatalf("CollectWarnings(no warnings) = %q, want nil", got)
+	}
+}
+
+func TestBuildDiffContext_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := BuildDiffContext(ctx, map[string]any{"a": 1}, map[string]any{"a": 2})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,237 @@
+package code
+
+import (
+	"bufio"
+	"bytes"
+	"code/ast"
+	"code/formatters"
+	"code/parsers"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+)
+
+type Pair struct {
+	Left   string `json:"left"`
+	Right  string `json:"right"`
+	Format string `json:"format,omitempty"`
+}
+
+type PairResult struct {
+	Index   int
+	Pair    Pair
+	Output  string
+	Changed bool
+	// Warnings lists the node warnings, with their key paths, that the
+	// pair's format leaves out of Output, or all of them when the pair has no
+	// changes and Output is not worth printing.
+	Warnings []string
+	Err      error
+}
+
+type BatchOptions struct {
+	// Workers bounds concurrent diffs; zero means runtime.NumCPU().
+	Workers int
+	// Format is used for pairs that do not name one.
+	Format string
+	Render formatters.Options
+	// InputFormat, when set, parses every file with that parser, as
+	// FileSourceAs does.
+	InputFormat string
+	// Limits applies to every file; a file over a limit fails its pair only.
+	Limits parsers.Limits
+	// Duplicates applies to every file, as in WithDuplicateKeys.
+	Duplicates parsers.DuplicateKeys
+}
+
+// ReadManifest reads pairs as CSV (left,right[,format]) or JSON lines
+// ({"left":…,"right":…,"format":…}), picked by the first non-blank byte.
+// Blank lines, "#" comments and a CSV "left,right,format" header are skipped.
+func ReadManifest(r io.Reader) ([]Pair, error) {
+	br := bufio.NewReader(r)
+	first, err := firstByte(br)
+	if err != nil {
+		return nil, err
+	}
+	if first == '{' {
+		return readJSONLines(br)
+	}
+	return readCSV(br)
+}
+
+func firstByte(br *bufio.Reader) (byte, error) {
+	for {
+		b, err := br.Peek(1)
+		if errors.Is(err, io.EOF) {
+			return 0, nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("read manifest: %w", err)
+		}
+		if !bytes.ContainsAny(b, " \t\r\n") {
+			return b[0], nil
+		}
+		if _, err := br.ReadByte(); err != nil {
+			return 0, fmt.Errorf("read manifest: %w", err)
+		}
+	}
+}
+
+func readJSONLines(r io.Reader) ([]Pair, error) {
+	var pairs []Pair
+	sc := bufio.NewScanner(r)
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		var p Pair
+		if err := json.Unmarshal([]byte(text), &p); err != nil {
+			return nil, fmt.Errorf("manifest line %d: %w", line, err)
+		}
+		if p.Left == "" || p.Right == "" {
+			return nil, fmt.Errorf("manifest line %d: left and right are required", line)
+		}
+		pairs = append(pairs, p)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read manifest: %w", err)
+	}
+	return pairs, nil
+}
+
+func readCSV(r io.Reader) ([]Pair, error) {
+	cr := csv.NewReader(r)
+	cr.Comment = '#'
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	var pairs []Pair
+	for {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return pairs, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("manifest: %w", err)
+		}
+		line, _ := cr.FieldPos(0)
+		if len(rec) < 2 || len(rec) > 3 {
+			return nil, fmt.Errorf("manifest line %d: want left,right[,format], got %d fields", line, len(rec))
+		}
+		if len(pairs) == 0 && rec[0] == "left" && rec[1] == "right" {
+			continue
+		}
+		p := Pair{Left: rec[0], Right: rec[1]}
+		if len(rec) == 3 {
+			p.Format = rec[2]
+		}
+		pairs = append(pairs, p)
+	}
+}
+
+// RunBatch diffs and renders pairs on a bounded worker pool and calls emit
+// for every pair in input order. A failing pair is reported through
+// PairResult.Err and does not stop the others; cancelling ctx does.
+func RunBatch(ctx context.Context, pairs []Pair, opts BatchOptions, emit func(PairResult)) error {
+	return forEachOrdered(ctx, len(pairs), opts.Workers, func(i int) PairResult {
+		p := pairs[i]
+		res := PairResult{Index: i, Pair: p}
+
+		diff, err := Diff(ctx, FileSourceAs(p.Left, opts.InputFormat), FileSourceAs(p.Right, opts.InputFormat), WithLimits(opts.Limits), WithDuplicateKeys(opts.Duplicates))
+		if err != nil {
+			res.Err = err
+			return res
+		}
+		nodes := diff.Nodes
+		res.Changed = diff.Changed
+
+		format := p.Format
+		if format == "" {
+			format = opts.Format
+		}
+		ro := opts.Render
+		ro.OldLabel, ro.NewLabel = p.Left, p.Right
+		res.Output, res.Err = formatters.RenderWithOptions(format, nodes, ro)
+		if !formatters.ShowsWarnings(format, ro) || !res.Changed {
+			res.Warnings = ast.CollectWarnings(nodes)
+		}
+		return res
+	}, emit)
+}
+
+// forEachOrdered runs work(0..n-1) on up to workers goroutines and hands the
+// results to emit strictly in index order, buffering ones that finish early.
+// A slow job holds back at most 2*workers started or buffered jobs behind it,
+// so memory stays bounded however long the input.
+func forEachOrdered[T any](ctx context.Context, n, workers int, work func(int) T, emit func(T)) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	workers = max(1, min(workers, n))
+
+	type done struct {
+		i int
+		r T
+	}
+	jobs := make(chan int)
+	results := make(chan done, workers)
+	// slots caps the jobs handed out but not emitted yet.
+	slots := make(chan struct{}, 2*workers)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	go func() {
+		defer close(jobs)
+		for i := 0; i < n; i++ {
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case jobs <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for w := 0; w < workers; w++ {
+		go func() {
+			for i := range jobs {
+				select {
+				case results <- done{i, work(i)}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	pending := make(map[int]T)
+	for next := 0; next < n; {
+		select {
+		case d := <-results:
+			pending[d.i] = d.r
+			for r, ok := pending[next]; ok; r, ok = pending[next] {
+				delete(pending, next)
+				emit(r)
+				<-slots
+				next++
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
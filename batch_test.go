@@ -0,0 +1,248 @@
+package code
+
+import (
+	"code/formatters"
+	"code/parsers"
+	"context"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestReadManifest_CSV(t *testing.T) {
+	t.Parallel()
+
+	in := "left,right,format\n# comment\na.json, b.json\nc.yaml,d.yaml,plain\n"
+	got, err := ReadManifest(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Pair{
+		{Left: "a.json", Right: "b.json"},
+		{Left: "c.yaml", Right: "d.yaml", Format: "plain"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("ReadManifest = %#v, want %#v", got, want)
+	}
+}
+
+func TestReadManifest_JSONLines(t *testing.T) {
+	t.Parallel()
+
+	in := "\n  {\"left\":\"a.json\",\"right\":\"b.json\",\"format\":\"json\"}\n\n{\"left\":\"c\",\"right\":\"d\"}\n"
+	got, err := ReadManifest(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Pair{
+		{Left: "a.json", Right: "b.json", Format: "json"},
+		{Left: "c", Right: "d"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("ReadManifest = %#v, want %#v", got, want)
+	}
+}
+
+func TestReadManifest_Errors(t *testing.T) {
+	t.Parallel()
+
+	for name, in := range map[string]string{
+		"csv one field":      "only\n",
+		"csv too many":       "a,b,c,d\n",
+		"json missing right": `{"left":"a"}`,
+		"json broken":        `{"left":`,
+	} {
+		if _, err := ReadManifest(strings.NewReader(in)); err == nil {
+			t.Fatalf("%s: expected error, got nil", name)
+		}
+	}
+}
+
+func TestRunBatch_OrderAndErrors(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"a.json": `{"a":1}`,
+		"b.json": `{"a":2}`,
+	})
+	a, b := filepath.Join(dir, "a.json"), filepath.Join(dir, "b.json")
+
+	pairs := []Pair{
+		{Left: a, Right: b, Format: "plain"},
+		{Left: a, Right: filepath.Join(dir, "missing.json")},
+		{Left: a, Right: a},
+		{Left: a, Right: b, Format: "nope"},
+	}
+
+	var got []PairResult
+	err := RunBatch(context.Background(), pairs, BatchOptions{Workers: 3, Format: "stylish"}, func(r PairResult) {
+		got = append(got, r)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != len(pairs) {
+		t.Fatalf("got %d results, want %d", len(got), len(pairs))
+	}
+	for i, r := range got {
+		if r.Index != i {
+			t.Fatalf("result %d has index %d", i, r.Index)
+		}
+	}
+	if got[0].Err != nil || !got[0].Changed || got[0].Output != "Property 'a' was updated. From 1 to 2" {
+		t.Fatalf("pair 0 = %#v", got[0])
+	}
+	if got[1].Err == nil {
+		t.Fatalf("pair 1: expected error for missing file")
+	}
+	if got[2].Err != nil || got[2].Changed || !strings.HasPrefix(got[2].Output, "{") {
+		t.Fatalf("pair 2 = %#v", got[2])
+	}
+	if got[3].Err == nil {
+		t.Fatalf("pair 3: expected unknown format error")
+	}
+}
+
+func TestRunBatch_InputFormat(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"a.conf": `{"a":1}`,
+		"b.conf": `{"a":2}`,
+	})
+	pairs := []Pair{{Left: filepath.Join(dir, "a.conf"), Right: filepath.Join(dir, "b.conf")}}
+
+	var got PairResult
+	err := RunBatch(context.Background(), pairs, BatchOptions{Format: "plain", InputFormat: "json"}, func(r PairResult) {
+		got = r
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Err != nil || got.Output != "Property 'a' was updated. From 1 to 2" {
+		t.Fatalf("pair = %#v", got)
+	}
+}
+
+func TestRunBatch_Warnings(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"a.json": `{"a":1,"a":2}`,
+		"b.json": `{"a":3}`,
+	})
+	a, b := filepath.Join(dir, "a.json"), filepath.Join(dir, "b.json")
+	pairs := []Pair{{Left: a, Right: b, Format: "plain"}, {Left: a, Right: b, Format: "unified"}, {Left: a, Right: a, Format: "plain"}}
+
+	var got []PairResult
+	err := RunBatch(context.Background(), pairs, BatchOptions{Duplicates: parsers.WarnDuplicates}, func(r PairResult) {
+		got = append(got, r)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got[0].Err != nil || got[0].Warnings != nil || !strings.Contains(got[0].Output, "duplicate key") {
+		t.Fatalf("plain pair = %#v, want the warning in Output only", got[0])
+	}
+	if got[1].Err != nil || len(got[1].Warnings) != 1 || !strings.HasPrefix(got[1].Warnings[0], `a: duplicate key "a"`) {
+		t.Fatalf("unified pair = %#v, want the warning in Warnings", got[1])
+	}
+	if got[2].Err != nil || got[2].Changed || len(got[2].Warnings) != 2 {
+		t.Fatalf("unchanged pair = %#v, want both warnings in Warnings", got[2])
+	}
+}
+
+func TestRunBatch_StatWarnings(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"a.json": `{"a":1,"a":2}`,
+		"b.json": `{"a":3}`,
+	})
+	pairs := []Pair{{Left: filepath.Join(dir, "a.json"), Right: filepath.Join(dir, "b.json"), Format: "json"}}
+
+	var got []PairResult
+	opts := BatchOptions{Duplicates: parsers.WarnDuplicates, Render: formatters.Options{Stat: true}}
+	if err := RunBatch(context.Background(), pairs, opts, func(r PairResult) { got = append(got, r) }); err != nil {
+		t.Fatal(err)
+	}
+	if got[0].Err != nil || len(got[0].Warnings) != 1 || strings.Contains(got[0].Output, "duplicate key") {
+		t.Fatalf("json --stat pair = %#v, want the warning in Warnings", got[0])
+	}
+}
+
+func TestForEachOrdered_EmitsInOrder(t *testing.T) {
+	t.Parallel()
+
+	const n = 20
+	var got []int
+	err := forEachOrdered(context.Background(), n, 4, func(i int) int {
+		time.Sleep(time.Duration(n-i) * time.Millisecond)
+		return i
+	}, func(i int) {
+		got = append(got, i)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range n {
+		if got[i] != i {
+			t.Fatalf("emit order = %v", got)
+		}
+	}
+}
+
+func TestForEachOrdered_BoundedReadAhead(t *testing.T) {
+	t.Parallel()
+
+	const n, workers = 50, 3
+	release := make(chan struct{})
+	var started atomic.Int32
+	done := make(chan error, 1)
+	go func() {
+		done <- forEachOrdered(context.Background(), n, workers, func(i int) int {
+			started.Add(1)
+			if i == 0 {
+				<-release
+			}
+			return i
+		}, func(int) {})
+	}()
+
+	// With job 0 stuck, the pool may only run ahead by its window.
+	deadline := time.Now().Add(2 * time.Second)
+	for started.Load() < 2*workers && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if got := started.Load(); got != 2*workers {
+		t.Fatalf("started %d jobs while the first was blocked, want %d", got, 2*workers)
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if got := started.Load(); got != n {
+		t.Fatalf("started %d jobs, want %d", got, n)
+	}
+}
+
+func TestForEachOrdered_Cancelled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := forEachOrdered(ctx, 5, 2, func(i int) int { return i }, func(int) {})
+	if err == nil {
+		t.Fatalf("expected context error, got nil")
+	}
+}
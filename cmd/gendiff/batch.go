@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"code"
+	urfaveCli "github.com/urfave/cli/v3"
+)
+
+func batchCommand() *urfaveCli.Command {
+	return &urfaveCli.Command{
+		Name:      "batch",
+		Usage:     "diff many file pairs in parallel",
+		UsageText: "gendiff batch --manifest pairs.csv\ngendiff batch <dir1> <dir2>",
+		Description: "The manifest holds left,right[,format] CSV rows or JSON lines with " +
+			"left/right/format keys; use - for stdin. Results are printed in manifest order.",
+		Flags: []urfaveCli.Flag{
+			&urfaveCli.StringFlag{
+				Name:    "manifest",
+				Aliases: []string{"m"},
+				Usage:   "CSV or JSON lines file listing the pairs to compare",
+			},
+		},
+		Action: runBatch,
+	}
+}
+
+func runBatch(ctx context.Context, cmd *urfaveCli.Command) error {
+	manifest := cmd.String("manifest")
+	if manifest == "" {
+		if cmd.Args().Len() != 2 || !isDir(cmd.Args().Get(0)) || !isDir(cmd.Args().Get(1)) {
+			return urfaveCli.Exit("usage: gendiff batch --manifest <file> | gendiff batch <dir1> <dir2>", exitTrouble)
+		}
+		return runDirs(ctx, cmd, cmd.Args().Get(0), cmd.Args().Get(1))
+	}
+
+	pairs, err := readManifest(manifest)
+	if err != nil {
+		return exitError(err)
+	}
+	opts, err := formatOptions(cmd)
+	if err != nil {
+		return exitError(err)
+	}
+	quiet := cmd.Bool("quiet")
+
+	status := exitSame
+	differ, failed := 0, 0
+	err = writeOutput(cmd, func(w io.Writer) error {
+		err := code.RunBatch(ctx, pairs, code.BatchOptions{
+			Workers:     int(cmd.Int("jobs")),
+			Format:      cmd.String("format"),
+			InputFormat: cmd.String("input-format"),
+			Render:      opts,
+			Limits:      limits(cmd),
+			Duplicates:  duplicateKeys(cmd),
+		}, func(r code.PairResult) {
+			switch {
+			case r.Err != nil:
+				failed++
+				status = exitTrouble
+				fmt.Fprintf(w, "error %s %s: %v\n", r.Pair.Left, r.Pair.Right, r.Err)
+			case r.Changed:
+				differ++
+				status = max(status, exitDiffer)
+				if !quiet {
+					fmt.Fprintf(w, "diff %s %s\n%s\n", r.Pair.Left, r.Pair.Right, r.Output)
+				}
+			}
+			if !quiet {
+				for _, warning := range r.Warnings {
+					fmt.Fprintf(os.Stderr, "gendiff: warning: %s\n", warning)
+				}
+			}
+		})
+		if err != nil {
+			return err
+		}
+
+		if !quiet {
+			fmt.Fprintf(w, "%d pairs compared, %d differ, %d failed\n", len(pairs), differ, failed)
+		}
+		return nil
+	})
+	if err != nil {
+		return exitError(err)
+	}
+	return urfaveCli.Exit("", status)
+}
+
+func readManifest(path string) ([]code.Pair, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("open manifest: %w", err)
+		}
+		defer f.Close()
+		r = f
+	}
+	return code.ReadManifest(r)
+}
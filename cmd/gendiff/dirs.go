@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"code"
+	"code/formatters"
+	urfaveCli "github.com/urfave/cli/v3"
+)
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+func runDirs(ctx context.Context, cmd *urfaveCli.Command, dir1, dir2 string) error {
+	opts, err := formatOptions(cmd)
+	if err != nil {
+		return exitError(err)
+	}
+	quiet := cmd.Bool("quiet")
+	format := cmd.String("format")
+
+	var summary code.DirSummary
+	status := exitSame
+	err = writeOutput(cmd, func(w io.Writer) error {
+		err := code.WalkDirs(ctx, dir1, dir2, code.DirOptions{
+			Include:     cmd.StringSlice("include"),
+			Exclude:     cmd.StringSlice("exclude"),
+			Workers:     int(cmd.Int("jobs")),
+			InputFormat: cmd.String("input-format"),
+			Limits:      limits(cmd),
+			Duplicates:  duplicateKeys(cmd),
+		}, func(r code.FileResult) {
+			if r.Status == code.Compared && r.Changed() && !quiet {
+				opts.OldLabel, opts.NewLabel = r.Left, r.Right
+				fmt.Fprintf(w, "diff %s %s\n", r.Left, r.Right)
+				if err := formatters.WriteContext(ctx, w, format, r.Nodes, opts); err != nil {
+					r.Status, r.Err = code.Failed, err
+				}
+				printWarnings(os.Stderr, format, opts, r.Nodes)
+			} else if r.Status == code.Compared && !quiet {
+				printAllWarnings(os.Stderr, r.Nodes)
+			}
+
+			summary.Add(r)
+			if r.Changed() {
+				status = max(status, exitDiffer)
+			}
+
+			switch r.Status {
+			case code.Failed:
+				status = exitTrouble
+				fmt.Fprintf(os.Stderr, "gendiff: %s: %v\n", r.Path, r.Err)
+			case code.OnlyLeft:
+				if !quiet {
+					fmt.Fprintf(w, "Only in %s: %s\n", dir1, r.Path)
+				}
+			case code.OnlyRight:
+				if !quiet {
+					fmt.Fprintf(w, "Only in %s: %s\n", dir2, r.Path)
+				}
+			case code.Unsupported:
+				if !quiet {
+					fmt.Fprintf(w, "Skipped %s: unsupported file extension\n", r.Path)
+				}
+			}
+		})
+		if err != nil {
+			return err
+		}
+
+		if !quiet {
+			fmt.Fprintf(w, "%d files compared, %d differ, %d only in %s, %d only in %s, %d skipped, %d failed\n",
+				summary.Compared, summary.Differ, summary.OnlyLeft, dir1, summary.OnlyRight, dir2, summary.Skipped, summary.Failed)
+		}
+		return nil
+	})
+	if err != nil {
+		return exitError(err)
+	}
+	return urfaveCli.Exit("", status)
+}
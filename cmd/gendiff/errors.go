@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"code"
+	"code/formatters/template"
+	"code/parsers"
+	urfaveCli "github.com/urfave/cli/v3"
+)
+
+// exitCode picks the status for a failure: a specific one for the error
+// classes scripts may want to tell apart, exitTrouble for everything else.
+func exitCode(err error) int {
+	switch {
+	case errors.Is(err, fs.ErrNotExist), errors.Is(err, fs.ErrPermission):
+		return exitNoInput
+	case errors.Is(err, code.ErrSyntax):
+		return exitSyntax
+	case errors.Is(err, code.ErrUnsupportedFormat):
+		return exitUnsupported
+	case errors.Is(err, code.ErrUnknownFormatter):
+		return exitUnknownFormatter
+	case errors.Is(err, code.ErrLimitExceeded):
+		return exitLimit
+	case errors.Is(err, code.ErrDuplicateKey):
+		return exitDuplicateKey
+	default:
+		return exitTrouble
+	}
+}
+
+// exitError turns err into a one-line message for the user and the matching
+// exit status.
+func exitError(err error) error {
+	return urfaveCli.Exit(friendly(err), exitCode(err))
+}
+
+func friendly(err error) string {
+	var (
+		pathErr     *fs.PathError
+		syntaxErr   *code.SyntaxError
+		unsupported *code.UnsupportedFormatError
+		unknown     *code.UnknownFormatterError
+		dupErr      *code.DuplicateKeyError
+	)
+	switch {
+	case errors.Is(err, context.Canceled):
+		return "interrupted"
+	case errors.Is(err, template.ErrNoTemplate):
+		return "no template given; use --template or --template-string"
+	case errors.As(err, &pathErr) && errors.Is(err, fs.ErrNotExist):
+		return "no such file: " + pathErr.Path
+	case errors.As(err, &pathErr) && errors.Is(err, fs.ErrPermission):
+		return "permission denied: " + pathErr.Path
+	case errors.As(err, &syntaxErr):
+		if frame := codeFrame(syntaxErr.File, syntaxErr.Line, syntaxErr.Column); frame != "" {
+			return syntaxErr.Error() + "\n" + frame
+		}
+		return syntaxErr.Error()
+	case errors.As(err, &unsupported):
+		what := fmt.Sprintf("unsupported format %q", unsupported.Format)
+		if unsupported.Extension {
+			what = fmt.Sprintf("unsupported file extension %q", unsupported.Format)
+		}
+		return fmt.Sprintf("%s%s; choose one with --input-format (%s)", source(err), what, strings.Join(parsers.Names(), ", "))
+	case errors.As(err, &unknown):
+		return fmt.Sprintf("unknown output format %q; choose one of %s", unknown.Name, strings.Join(unknown.Known, ", "))
+	case errors.As(err, &dupErr):
+		return duplicatesMessage(dupErr)
+	case errors.Is(err, code.ErrLimitExceeded):
+		return fmt.Sprintf("%s%v%s", source(err), innermost(err), limitHint(err))
+	default:
+		return err.Error()
+	}
+}
+
+// duplicatesMessage lists every repeated key, with a code frame for the first.
+func duplicatesMessage(err *code.DuplicateKeyError) string {
+	var b strings.Builder
+	for i, d := range err.Duplicates {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "%s:%d:%d: duplicate key %q, first defined at line %d, column %d", err.File, d.Line, d.Column, d.String(), d.FirstLine, d.FirstColumn)
+		if i == 0 {
+			if frame := codeFrame(err.File, d.Line, d.Column); frame != "" {
+				b.WriteString("\n" + frame)
+			}
+		}
+	}
+	return b.String()
+}
+
+// source prefixes a message with the input that caused it, when known.
+func source(err error) string {
+	var srcErr *code.SourceError
+	if errors.As(err, &srcErr) {
+		return srcErr.Source + ": "
+	}
+	return ""
+}
+
+// innermost drops the "parse %q:" style context wrapped around err.
+func innermost(err error) error {
+	for {
+		next := errors.Unwrap(err)
+		if next == nil {
+			return err
+		}
+		err = next
+	}
+}
+
+// limitHint names the flag that raises the limit err reports.
+func limitHint(err error) string {
+	var (
+		size  *parsers.SizeLimitError
+		depth *parsers.DepthLimitError
+		nodes *parsers.NodeLimitError
+		alias *parsers.AliasLimitError
+	)
+	switch {
+	case errors.As(err, &size):
+		return " (raise it with --max-bytes)"
+	case errors.As(err, &depth):
+		return " (raise it with --max-depth)"
+	case errors.As(err, &nodes):
+		return " (raise it with --max-nodes)"
+	case errors.As(err, &alias):
+		return " (raise it with --max-aliases)"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"testing"
+
+	"code"
+	"code/formatters/template"
+	"code/parsers"
+)
+
+func TestExitCodeAndFriendly(t *testing.T) {
+	source := func(name string, err error) error {
+		return &code.SourceError{Source: name, Err: fmt.Errorf("parse %q: %w", name, err)}
+	}
+	cases := []struct {
+		name string
+		err  error
+		code int
+		msg  string
+	}{
+		{
+			name: "missing file",
+			err:  source("a.json", &fs.PathError{Op: "open", Path: "a.json", Err: fs.ErrNotExist}),
+			code: exitNoInput,
+			msg:  "no such file: a.json",
+		},
+		{
+			name: "unreadable file",
+			err:  source("a.json", &fs.PathError{Op: "open", Path: "a.json", Err: fs.ErrPermission}),
+			code: exitNoInput,
+			msg:  "permission denied: a.json",
+		},
+		{
+			name: "syntax error without a readable file",
+			err:  source("rev:a.json", &parsers.SyntaxError{Format: "json", File: "rev:a.json", Line: 2, Column: 3, Err: errors.New("invalid character '}'")}),
+			code: exitSyntax,
+			msg:  "rev:a.json:2:3: invalid JSON: invalid character '}'",
+		},
+		{
+			name: "unsupported extension",
+			err:  source("a.toml", &parsers.UnsupportedFormatError{Format: ".toml", Extension: true}),
+			code: exitUnsupported,
+			msg:  `a.toml: unsupported file extension ".toml"; choose one with --input-format (json, yaml, yml)`,
+		},
+		{
+			name: "unsupported input format",
+			err:  &parsers.UnsupportedFormatError{Format: "bogus"},
+			code: exitUnsupported,
+			msg:  `unsupported format "bogus"; choose one with --input-format (json, yaml, yml)`,
+		},
+		{
+			name: "unknown output format",
+			err:  &code.UnknownFormatterError{Name: "nope", Known: []string{"json", "plain"}},
+			code: exitUnknownFormatter,
+			msg:  `unknown output format "nope"; choose one of json, plain`,
+		},
+		{
+			name: "size limit",
+			err:  source("a.json", fmt.Errorf("read %q: %w", "a.json", &parsers.SizeLimitError{Size: 10, Limit: 5})),
+			code: exitLimit,
+			msg:  "a.json: input of 10 bytes exceeds the 5 byte limit (raise it with --max-bytes)",
+		},
+		{
+			name: "alias limit",
+			err:  source("a.yaml", &parsers.AliasLimitError{Limit: 3}),
+			code: exitLimit,
+			msg:  "a.yaml: " + (&parsers.AliasLimitError{Limit: 3}).Error() + " (raise it with --max-aliases)",
+		},
+		{
+			name: "duplicate keys",
+			err: source("rev:a.json", &parsers.DuplicateKeyError{File: "rev:a.json", Duplicates: []parsers.DuplicateKey{
+				{Path: []string{"a", "b"}, Line: 3, Column: 5, FirstLine: 2, FirstColumn: 5},
+				{Path: []string{"c"}, Line: 4, Column: 1, FirstLine: 1, FirstColumn: 2},
+			}}),
+			code: exitDuplicateKey,
+			msg: "rev:a.json:3:5: duplicate key \"a.b\", first defined at line 2, column 5\n" +
+				"rev:a.json:4:1: duplicate key \"c\", first defined at line 1, column 2",
+		},
+		{
+			name: "no template",
+			err:  template.ErrNoTemplate,
+			code: exitTrouble,
+			msg:  "no template given; use --template or --template-string",
+		},
+		{
+			name: "interrupted",
+			err:  fmt.Errorf("render: %w", context.Canceled),
+			code: exitTrouble,
+			msg:  "interrupted",
+		},
+		{
+			name: "anything else",
+			err:  errors.New("boom"),
+			code: exitTrouble,
+			msg:  "boom",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := exitCode(tc.err); got != tc.code {
+				t.Errorf("exitCode() = %d, want %d", got, tc.code)
+			}
+			if got := friendly(tc.err); got != tc.msg {
+				t.Errorf("friendly() =\n%s\nwant\n%s", got, tc.msg)
+			}
+		})
+	}
+}
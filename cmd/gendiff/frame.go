@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	// frameContext is the number of lines shown above and below the error.
+	frameContext = 1
+	// frameWidth caps how much of a line is shown, so minified documents
+	// print a window around the column instead of the whole line.
+	frameWidth = 100
+)
+
+// codeFrame shows the lines of path around line, with a caret under column
+// unless it is 0, the way compilers point at a syntax error:
+//
+//	  2 |   "a": 1,
+//	> 3 |   "b": }
+//	    |        ^
+//
+// It returns "" when path cannot be read, as for a git revision.
+func codeFrame(path string, line, column int) string {
+	if line <= 0 {
+		return ""
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	first := max(line-frameContext, 1)
+	var lines []frameLine
+	br := bufio.NewReader(f)
+	for n := 1; n <= line+frameContext; n++ {
+		l, err := readLine(br, column)
+		if n >= first && (l.length > 0 || err == nil || n == line) {
+			lines = append(lines, l)
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return ""
+		}
+	}
+	if line-first >= len(lines) {
+		return ""
+	}
+
+	start := window(lines[line-first].length, column)
+	width := len(strconv.Itoa(first + len(lines) - 1))
+	var b strings.Builder
+	for i, l := range lines {
+		text, col := clip(l, start, column)
+		if first+i != line {
+			fmt.Fprintf(&b, "  %*d | %s\n", width, first+i, text)
+			continue
+		}
+		fmt.Fprintf(&b, "> %*d | %s\n", width, line, text)
+		if column > 0 {
+			fmt.Fprintf(&b, "  %*s | %s^\n", width, "", caretIndent(text, col))
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// frameLine is what a frame keeps of a line: the runes from index from on,
+// out of length runes in all.
+type frameLine struct {
+	runes  []rune
+	from   int
+	length int
+}
+
+// readLine reads the next line from br without its line ending, keeping only
+// the runes a frame around column can show, so a minified document is never
+// held whole. Like ReadString, it returns io.EOF with a last line that has no
+// newline.
+func readLine(br *bufio.Reader, column int) (frameLine, error) {
+	l := frameLine{from: max(column-2-frameWidth, 0)}
+	to := max(column-1, 0) + frameWidth
+	add := func(r rune) {
+		if l.length >= l.from && l.length < to {
+			l.runes = append(l.runes, r)
+		}
+		l.length++
+	}
+	crs := 0
+	for {
+		r, _, err := br.ReadRune()
+		if err != nil || r == '\n' {
+			return l, err
+		}
+		if r == '\r' {
+			crs++
+			continue
+		}
+		for ; crs > 0; crs-- {
+			add('\r')
+		}
+		add(r)
+	}
+}
+
+// slice returns the kept runes from index i up to j of the line.
+func (l frameLine) slice(i, j int) []rune {
+	i = min(max(i-l.from, 0), len(l.runes))
+	j = min(max(j-l.from, i), len(l.runes))
+	return l.runes[i:j]
+}
+
+// window returns the first rune shown of a line of length runes, keeping
+// column in view.
+func window(length, column int) int {
+	if length <= frameWidth {
+		return 0
+	}
+	return max(min(column-1-frameWidth/2, length-frameWidth), 0)
+}
+
+// clip cuts l to frameWidth runes from start, marking cut ends with an
+// ellipsis, and moves column along with the text.
+func clip(l frameLine, start, column int) (string, int) {
+	if start == 0 && l.length <= frameWidth {
+		return string(l.runes), column
+	}
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+		column = column - start + 1
+	}
+	if start < l.length {
+		end := min(start+frameWidth, l.length)
+		b.WriteString(string(l.slice(start, end)))
+		if end < l.length {
+			b.WriteString("…")
+		}
+	}
+	return b.String(), column
+}
+
+// caretIndent blanks the text before column, keeping tabs so the caret lines
+// up under tab-indented source.
+func caretIndent(text string, column int) string {
+	var b strings.Builder
+	for i, r := range []rune(text) {
+		if i >= column-1 {
+			break
+		}
+		if r == '\t' {
+			b.WriteRune('\t')
+		} else {
+			b.WriteRune(' ')
+		}
+	}
+	for n := len([]rune(text)); n < column-1; n++ {
+		b.WriteRune(' ')
+	}
+	return b.String()
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCodeFrame(t *testing.T) {
+	long := "{" + strings.Repeat(`"k":1,`, 40) + "}"
+	cases := []struct {
+		name, doc    string
+		line, column int
+		want         string
+	}{
+		{
+			name: "context above", doc: "{\n  \"a\": 1,\n  \"b\": }\n", line: 3, column: 8,
+			want: "  2 |   \"a\": 1,\n> 3 |   \"b\": }\n    |        ^",
+		},
+		{
+			name: "first line", doc: "{\n  \"a\": 1,\n  \"b\": }\n", line: 1, column: 1,
+			want: "> 1 | {\n    | ^\n  2 |   \"a\": 1,",
+		},
+		{
+			name: "tab indent", doc: "a:\n\tb: 1\n", line: 2, column: 3,
+			want: "  1 | a:\n> 2 | \tb: 1\n    | \t ^",
+		},
+		{
+			name: "unknown column", doc: "a:\n\tb: 1\n", line: 2, column: 0,
+			want: "  1 | a:\n> 2 | \tb: 1",
+		},
+		{
+			name: "past the end of the line", doc: "{\"a\":", line: 1, column: 7,
+			want: "> 1 | {\"a\":\n    |       ^",
+		},
+		{
+			name: "wide line numbers", doc: strings.Repeat("x\n", 9) + "y\n", line: 10, column: 1,
+			want: "   9 | x\n> 10 | y\n     | ^",
+		},
+		{
+			name: "clipped", doc: long, line: 1, column: 150,
+			want: "> 1 | …" + long[99:199] + "…\n    | " + strings.Repeat(" ", 51) + "^",
+		},
+		{
+			name: "crlf", doc: "{\r\n  \"a\": }\r\n", line: 2, column: 8,
+			want: "  1 | {\n> 2 |   \"a\": }\n    |        ^",
+		},
+		{name: "line past the end", doc: "{}\n", line: 9, column: 1, want: ""},
+		{name: "no line", doc: "{}\n", line: 0, column: 0, want: ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := filepath.Join(t.TempDir(), "doc")
+			if err := os.WriteFile(p, []byte(tc.doc), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if got := codeFrame(p, tc.line, tc.column); got != tc.want {
+				t.Errorf("codeFrame() =\n%s\nwant\n%s", got, tc.want)
+			}
+		})
+	}
+
+	if got := codeFrame(filepath.Join(t.TempDir(), "missing"), 1, 1); got != "" {
+		t.Errorf("codeFrame(missing file) = %q, want \"\"", got)
+	}
+}
+
+func TestWindowAndClip(t *testing.T) {
+	line := []rune(strings.Repeat("a", 90) + "é" + strings.Repeat("b", 109))
+	cases := []struct {
+		name          string
+		line          []rune
+		column        int
+		start         int
+		text          string
+		clippedColumn int
+	}{
+		{"short line", []rune("abc"), 2, 0, "abc", 2},
+		{"column near the start", line, 10, 0, string(line[:100]) + "…", 10},
+		{"column in the middle", line, 91, 40, "…" + string(line[40:140]) + "…", 52},
+		{"column near the end", line, 195, 100, "…" + string(line[100:]), 96},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			start := window(len(tc.line), tc.column)
+			if start != tc.start {
+				t.Fatalf("window() = %d, want %d", start, tc.start)
+			}
+			text, column := clip(frameLine{runes: tc.line, length: len(tc.line)}, start, tc.column)
+			if text != tc.text || column != tc.clippedColumn {
+				t.Errorf("clip() = %q, %d; want %q, %d", text, column, tc.text, tc.clippedColumn)
+			}
+		})
+	}
+}
+
+func TestReadLine(t *testing.T) {
+	doc := strings.Repeat("a", 5000) + "b" + strings.Repeat("c", 5000) + "\r\nnext"
+	br := bufio.NewReader(strings.NewReader(doc))
+	l, err := readLine(br, 5001)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.length != 10001 {
+		t.Errorf("length = %d, want 10001", l.length)
+	}
+	if len(l.runes) > 2*frameWidth+1 {
+		t.Errorf("kept %d runes, want at most %d", len(l.runes), 2*frameWidth+1)
+	}
+	if got := string(l.slice(4999, 5002)); got != "abc" {
+		t.Errorf("slice around the column = %q, want %q", got, "abc")
+	}
+	l, err = readLine(br, 1)
+	if err != io.EOF || string(l.runes) != "next" {
+		t.Errorf("last line = %q, %v; want %q, EOF", string(l.runes), err, "next")
+	}
+}
+
+func TestCaretIndent(t *testing.T) {
+	cases := []struct {
+		text   string
+		column int
+		want   string
+	}{
+		{"abc", 1, ""},
+		{"abc", 3, "  "},
+		{"\t\tx", 3, "\t\t"},
+		{"\tx y", 4, "\t  "},
+		{"ab", 5, "    "},
+		{"é…x", 3, "  "},
+	}
+	for _, tc := range cases {
+		if got := caretIndent(tc.text, tc.column); got != tc.want {
+			t.Errorf("caretIndent(%q, %d) = %q, want %q", tc.text, tc.column, got, tc.want)
+		}
+	}
+}
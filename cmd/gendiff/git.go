@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"code/ast"
+	"code/formatters"
+	"code/formatters/unified"
+	"code/gitdiff"
+	"code/parsers"
+	urfaveCli "github.com/urfave/cli/v3"
+)
+
+func gitCommand() *urfaveCli.Command {
+	return &urfaveCli.Command{
+		Name:      "git",
+		Usage:     "diff a config between git revisions or act as a git diff driver",
+		UsageText: "gendiff git <rev1> <rev2> -- <path>",
+		Action:    runGitRevisions,
+		Commands: []*urfaveCli.Command{
+			{
+				Name:      "driver",
+				Usage:     "external diff entry point for diff.gendiff.command",
+				UsageText: "gendiff git driver <path> <old-file> <old-hex> <old-mode> <new-file> <new-hex> <new-mode>",
+				Action:    runGitDriver,
+			},
+			{
+				Name:      "textconv",
+				Usage:     "print a canonical form of the file for diff.gendiff.textconv",
+				UsageText: "gendiff git textconv [--syntax yaml] <file>",
+				Action:    runGitTextconv,
+			},
+			{
+				Name:      "setup",
+				Usage:     "register gendiff as the diff driver of the current repository",
+				UsageText: "gendiff git setup [--textconv] [--command gendiff] [pattern...]",
+				Flags: []urfaveCli.Flag{
+					&urfaveCli.BoolFlag{
+						Name:  "textconv",
+						Usage: "register a textconv filter instead of an external diff command",
+					},
+					&urfaveCli.StringFlag{
+						Name:  "command",
+						Usage: "command git should invoke",
+						Value: "gendiff",
+					},
+				},
+				Action: runGitSetup,
+			},
+		},
+	}
+}
+
+func runGitRevisions(ctx context.Context, cmd *urfaveCli.Command) error {
+	args := cmd.Args().Slice()
+	if len(args) == 4 && args[2] == "--" {
+		args = append(args[:2], args[3])
+	}
+	if len(args) != 3 {
+		return urfaveCli.Exit("usage: gendiff git <rev1> <rev2> -- <path>", exitTrouble)
+	}
+	rev1, rev2, path := args[0], args[1], args[2]
+
+	nodes, err := gitdiff.DiffRevisions(ctx, ".", rev1, rev2, path, parsers.Options{Limits: limits(cmd), Duplicates: duplicateKeys(cmd)})
+	if err != nil {
+		return exitError(err)
+	}
+	return printDiff(ctx, cmd, nodes, rev1+":"+path, rev2+":"+path)
+}
+
+// runGitDriver always exits 0 on success: git aborts the whole diff when an
+// external driver reports a non-zero status.
+func runGitDriver(ctx context.Context, cmd *urfaveCli.Command) error {
+	if cmd.Args().Len() < 7 {
+		return urfaveCli.Exit("usage: gendiff git driver <path> <old-file> <old-hex> <old-mode> <new-file> <new-hex> <new-mode>", exitTrouble)
+	}
+	path := cmd.Args().Get(0)
+	oldFile, newFile := cmd.Args().Get(1), cmd.Args().Get(4)
+
+	fmt.Printf("diff --gendiff a/%s b/%s\n", path, path)
+	nodes, err := gitdiff.DiffDriverFiles(ctx, path, oldFile, newFile, parsers.Options{Limits: limits(cmd), Duplicates: duplicateKeys(cmd)})
+	if err != nil {
+		fmt.Printf("gendiff: %v\n", err)
+		return nil
+	}
+
+	opts, err := formatOptions(cmd)
+	if err != nil {
+		return exitError(err)
+	}
+	opts.OldLabel, opts.NewLabel = "a/"+path, "b/"+path
+	if err := formatters.Write(os.Stdout, cmd.String("format"), nodes, opts); err != nil {
+		return exitError(err)
+	}
+	return nil
+}
+
+func runGitTextconv(ctx context.Context, cmd *urfaveCli.Command) error {
+	if cmd.Args().Len() != 1 {
+		return urfaveCli.Exit("usage: gendiff git textconv <file>", exitTrouble)
+	}
+	path := cmd.Args().First()
+	doc, err := parsers.ParseFileContext(ctx, path, "", parsers.Options{Limits: limits(cmd), Duplicates: duplicateKeys(cmd)})
+	if err != nil {
+		return exitError(err)
+	}
+	lines, err := unified.Document(doc, cmd.String("syntax"))
+	if err != nil {
+		return exitError(err)
+	}
+	fmt.Println(strings.Join(lines, "\n"))
+	return nil
+}
+
+func runGitSetup(ctx context.Context, cmd *urfaveCli.Command) error {
+	err := gitdiff.Setup(ctx, ".", gitdiff.SetupOptions{
+		Command:  cmd.String("command"),
+		Textconv: cmd.Bool("textconv"),
+		Patterns: cmd.Args().Slice(),
+	})
+	if err != nil {
+		return exitError(err)
+	}
+	return nil
+}
+
+func printDiff(ctx context.Context, cmd *urfaveCli.Command, nodes []ast.Node, oldLabel, newLabel string) error {
+	status := exitSame
+	if ast.HasChanges(nodes) {
+		status = exitDiffer
+	}
+	if cmd.Bool("quiet") {
+		return urfaveCli.Exit("", status)
+	}
+
+	opts, err := formatOptions(cmd)
+	if err != nil {
+		return exitError(err)
+	}
+	opts.OldLabel, opts.NewLabel = oldLabel, newLabel
+
+	err = writeOutput(cmd, func(w io.Writer) error {
+		return formatters.WriteContext(ctx, w, cmd.String("format"), nodes, opts)
+	})
+	if err != nil {
+		return exitError(err)
+	}
+	printWarnings(os.Stderr, cmd.String("format"), opts, nodes)
+	return urfaveCli.Exit("", status)
+}
+
+// printWarnings lists the node warnings format leaves out of its output under
+// opts, such as repeated keys under --duplicate-keys warn, so they are not lost.
+func printWarnings(w io.Writer, format string, opts formatters.Options, nodes []ast.Node) {
+	if formatters.ShowsWarnings(format, opts) {
+		return
+	}
+	printAllWarnings(w, nodes)
+}
+
+// printAllWarnings lists every node warning, for a diff whose output is not
+// printed at all.
+func printAllWarnings(w io.Writer, nodes []ast.Node) {
+	for _, warning := range ast.CollectWarnings(nodes) {
+		fmt.Fprintf(w, "gendiff: warning: %s\n", warning)
+	}
+}
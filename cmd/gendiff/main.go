@@ -2,47 +2,373 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
 
 	"code"
+	"code/formatters"
+	"code/parsers"
+	"code/stream"
+	"code/watch"
 	urfaveCli "github.com/urfave/cli/v3"
 )
 
+// Exit statuses follow diff(1): 0 same, 1 different, 2 and above trouble,
+// with the causes scripts may want to tell apart numbered from 3.
+const (
+	exitSame             = 0
+	exitDiffer           = 1
+	exitTrouble          = 2
+	exitNoInput          = 3
+	exitSyntax           = 4
+	exitUnsupported      = 5
+	exitUnknownFormatter = 6
+	exitLimit            = 7
+	exitDuplicateKey     = 8
+)
+
 func main() {
 	app := newApp()
-	if err := app.Run(context.Background(), os.Args); err != nil {
-		os.Exit(1)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := app.Run(ctx, os.Args); err != nil {
+		os.Exit(exitCode(err))
 	}
 }
 
 func newApp() *urfaveCli.Command {
 	return &urfaveCli.Command{
-		Name:      "gendiff",
-		Usage:     "Compares two configuration files and shows a difference.",
+		Name:  "gendiff",
+		Usage: "Compares two configuration files and shows a difference.",
+		Description: "Exit status is 0 if the files are the same, 1 if they differ and 2 on trouble: " +
+			"3 when an input is missing or unreadable, 4 on a syntax error, 5 for an unsupported input format, " +
+			"6 for an unknown output format, 7 when an input exceeds a --max-* limit, " +
+			"8 for a duplicate key with --duplicate-keys strict and 2 otherwise.",
 		UsageText: "gendiff [--format stylish] <file1> <file2>",
 		Flags: []urfaveCli.Flag{
+			&urfaveCli.BoolFlag{
+				Name:    "quiet",
+				Aliases: []string{"q"},
+				Usage:   "print nothing; report differences through the exit status only",
+			},
 			&urfaveCli.StringFlag{
 				Name:    "format",
 				Aliases: []string{"f"},
-				Usage:   "output format (stylish, plain, json)",
-				Value:   "stylish",
+				Usage:   "output format (" + strings.Join(formatters.Names(), ", ") + ")",
+				Value:   formatters.DefaultFormat,
+			},
+			&urfaveCli.StringFlag{
+				Name:    "output",
+				Aliases: []string{"o"},
+				Usage:   "write the diff to this file, replaced atomically once rendering succeeds; - is stdout",
+			},
+			&urfaveCli.StringFlag{
+				Name:  "input-format",
+				Usage: "parse both files as this format (" + strings.Join(parsers.Names(), ", ") + ", a MIME type or an extension) instead of by extension",
+			},
+			&urfaveCli.StringFlag{
+				Name:  "template",
+				Usage: "text/template file for --format template",
+			},
+			&urfaveCli.StringFlag{
+				Name:  "template-string",
+				Usage: "inline text/template for --format template",
+			},
+			&urfaveCli.StringSliceFlag{
+				Name:  "format-opt",
+				Usage: "formatter-specific option as key=value (repeatable)",
+			},
+			&urfaveCli.StringSliceFlag{
+				Name:  "include",
+				Usage: "in directory mode, compare only paths matching the glob (repeatable)",
+			},
+			&urfaveCli.StringSliceFlag{
+				Name:  "exclude",
+				Usage: "in directory mode, skip paths matching the glob (repeatable)",
+			},
+			&urfaveCli.BoolFlag{
+				Name:    "watch",
+				Aliases: []string{"w"},
+				Usage:   "keep running and re-render whenever either file changes",
+			},
+			&urfaveCli.DurationFlag{
+				Name:  "watch-interval",
+				Usage: "polling interval for --watch when file system events are unavailable or --watch-poll is set",
+				Value: watch.DefaultInterval,
+			},
+			&urfaveCli.BoolFlag{
+				Name:  "watch-poll",
+				Usage: "poll for --watch instead of waiting for file system events, as needed on network mounts",
+			},
+			&urfaveCli.IntFlag{
+				Name:    "jobs",
+				Aliases: []string{"j"},
+				Usage:   "number of files diffed in parallel in directory and batch mode; 0 uses all CPUs",
+			},
+			&urfaveCli.BoolFlag{
+				Name:  "stream",
+				Usage: "large-file mode for JSON: decode both files token by token and report only changes; two files only, not with --watch, --input-format, --max-* or --duplicate-keys",
+			},
+			&urfaveCli.BoolFlag{
+				Name:  "sorted-keys",
+				Usage: "with --stream, promise that every object lists its keys in sorted order so nothing is buffered",
+			},
+			&urfaveCli.Int64Flag{
+				Name:  "max-bytes",
+				Usage: "reject input files larger than this many bytes; 0 disables the limit",
+				Value: parsers.DefaultLimits().MaxBytes,
+			},
+			&urfaveCli.IntFlag{
+				Name:  "max-depth",
+				Usage: "reject documents nested deeper than this; 0 disables the limit",
+				Value: parsers.DefaultLimits().MaxDepth,
+			},
+			&urfaveCli.IntFlag{
+				Name:  "max-nodes",
+				Usage: "reject documents with more values than this; 0 disables the limit",
+				Value: parsers.DefaultLimits().MaxNodes,
+			},
+			&urfaveCli.IntFlag{
+				Name:  "max-aliases",
+				Usage: "reject YAML documents with more alias expansions than this; 0 disables the limit",
+				Value: parsers.DefaultLimits().MaxAliases,
+			},
+			&urfaveCli.StringFlag{
+				Name:      "duplicate-keys",
+				Usage:     "what a key repeated within one object does: allow it (JSON only; YAML forbids repeated keys), warn next to the node in the output (on stderr for formats that cannot show it), or strict to fail (not with --stream)",
+				Value:     "warn",
+				Validator: validateDuplicateKeys,
+			},
+			&urfaveCli.BoolFlag{
+				Name:  "stat",
+				Usage: "print change statistics instead of the diff (json with --format json)",
+			},
+			&urfaveCli.IntFlag{
+				Name:    "context",
+				Aliases: []string{"U"},
+				Usage:   "number of context lines for the unified format",
+				Value:   3,
+			},
+			&urfaveCli.StringFlag{
+				Name:  "color",
+				Usage: "colorize stylish and plain output (auto, always, never)",
+				Value: "auto",
+			},
+			&urfaveCli.IntFlag{
+				Name:  "width",
+				Usage: "total width for the side-by-side format; 0 uses $COLUMNS or 120",
+			},
+			&urfaveCli.BoolFlag{
+				Name:  "wrap",
+				Usage: "wrap long values in the side-by-side format instead of truncating",
+			},
+			&urfaveCli.StringFlag{
+				Name:  "syntax",
+				Usage: "canonical syntax for the unified format (json, yaml)",
+				Value: "json",
 			},
 		},
-		Action: func(ctx context.Context, cmd *urfaveCli.Command) error {
-			if cmd.Args().Len() != 2 {
-				return urfaveCli.Exit("usage: gendiff [--format stylish] <file1> <file2>", 2)
-			}
-			f1 := cmd.Args().First()
-			f2 := cmd.Args().Tail()[0]
-			format := cmd.String("format")
-
-			out, err := code.GenDiff(f1, f2, format)
-			if err != nil {
-				return urfaveCli.Exit(err.Error(), 1)
-			}
-			fmt.Println(out)
-			return nil
+		Commands: []*urfaveCli.Command{
+			batchCommand(),
+			gitCommand(),
+			serveCommand(),
 		},
+		Before: validateFormats,
+		Action: runDiff,
+	}
+}
+
+// validateFormats rejects an unknown --format or --input-format before any
+// input is read. They are not flag Validators because those errors reach main
+// without their type.
+func validateFormats(ctx context.Context, cmd *urfaveCli.Command) (context.Context, error) {
+	if err := formatters.Validate(cmd.String("format")); err != nil {
+		return ctx, exitError(err)
+	}
+	if format := cmd.String("input-format"); format != "" {
+		if _, ok := parsers.Lookup(format); !ok {
+			return ctx, exitError(&parsers.UnsupportedFormatError{Format: format})
+		}
+	}
+	return ctx, nil
+}
+
+func runDiff(ctx context.Context, cmd *urfaveCli.Command) error {
+	if cmd.Args().Len() != 2 {
+		return urfaveCli.Exit("usage: gendiff [--format stylish] <file1> <file2>", exitTrouble)
+	}
+	f1 := cmd.Args().First()
+	f2 := cmd.Args().Tail()[0]
+	if err := checkStream(cmd, f1, f2); err != nil {
+		return urfaveCli.Exit(err.Error(), exitTrouble)
+	}
+
+	switch dir1, dir2 := isDir(f1), isDir(f2); {
+	case dir1 && dir2:
+		return runDirs(ctx, cmd, f1, f2)
+	case dir1 != dir2:
+		return urfaveCli.Exit("cannot compare a directory with a file", exitTrouble)
+	}
+
+	if cmd.Bool("watch") {
+		return runWatch(ctx, cmd, f1, f2)
+	}
+
+	var (
+		res *code.Result
+		err error
+	)
+	if cmd.Bool("stream") {
+		res, err = code.DiffLargeFiles(ctx, f1, f2, stream.Options{Sorted: cmd.Bool("sorted-keys")})
+	} else {
+		inputFormat := cmd.String("input-format")
+		res, err = code.Diff(ctx, code.FileSourceAs(f1, inputFormat), code.FileSourceAs(f2, inputFormat), code.WithLimits(limits(cmd)), code.WithDuplicateKeys(duplicateKeys(cmd)))
+	}
+	if err != nil {
+		return exitError(err)
+	}
+	return printDiff(ctx, cmd, res.Nodes, f1, f2)
+}
+
+// streamIgnored lists the flags --stream has no way to honour.
+var streamIgnored = []string{"input-format", "max-bytes", "max-depth", "max-nodes", "max-aliases"}
+
+// checkStream rejects what --stream would otherwise quietly ignore: flags it
+// does not support, modes other than comparing two files, and files that are
+// not JSON. Without --stream it rejects --sorted-keys, which nothing else uses.
+func checkStream(cmd *urfaveCli.Command, files ...string) error {
+	if !cmd.Bool("stream") {
+		if cmd.IsSet("sorted-keys") {
+			return errors.New("--sorted-keys only applies with --stream")
+		}
+		return nil
+	}
+	if cmd.Bool("watch") {
+		return errors.New("--stream cannot be combined with --watch")
+	}
+	for _, f := range files {
+		if isDir(f) {
+			return fmt.Errorf("--stream compares two files, not directories: %s", f)
+		}
+	}
+	for _, name := range streamIgnored {
+		if cmd.IsSet(name) {
+			return fmt.Errorf("--stream cannot be combined with --%s", name)
+		}
+	}
+	if cmd.IsSet("duplicate-keys") && duplicateKeys(cmd) != parsers.AllowDuplicates {
+		return fmt.Errorf("--stream does not detect duplicate keys; drop --duplicate-keys %s", cmd.String("duplicate-keys"))
+	}
+	jsonParser, _ := parsers.ByName("json")
+	for _, f := range files {
+		if p, ok := parsers.ByExtension(filepath.Ext(f)); !ok || p != jsonParser {
+			return fmt.Errorf("--stream reads JSON only: %s", f)
+		}
+	}
+	return nil
+}
+
+func limits(cmd *urfaveCli.Command) parsers.Limits {
+	return parsers.Limits{
+		MaxBytes:   cmd.Int64("max-bytes"),
+		MaxDepth:   int(cmd.Int("max-depth")),
+		MaxNodes:   int(cmd.Int("max-nodes")),
+		MaxAliases: int(cmd.Int("max-aliases")),
+	}
+}
+
+var duplicateKeyModes = map[string]parsers.DuplicateKeys{
+	"allow":  parsers.AllowDuplicates,
+	"warn":   parsers.WarnDuplicates,
+	"strict": parsers.RejectDuplicates,
+}
+
+func duplicateKeys(cmd *urfaveCli.Command) parsers.DuplicateKeys {
+	return duplicateKeyModes[cmd.String("duplicate-keys")]
+}
+
+func validateDuplicateKeys(mode string) error {
+	if _, ok := duplicateKeyModes[mode]; !ok {
+		return fmt.Errorf("unknown duplicate key mode %q (allow, warn, strict)", mode)
+	}
+	return nil
+}
+
+func formatOptions(cmd *urfaveCli.Command) (formatters.Options, error) {
+	opts := formatters.DefaultOptions()
+	opts.Context = int(cmd.Int("context"))
+	opts.Syntax = cmd.String("syntax")
+	out := os.Stdout
+	if outputPath(cmd) != "" {
+		out = nil
+	}
+	useColor, err := colorEnabled(cmd.String("color"), out)
+	if err != nil {
+		return opts, err
+	}
+	opts.Color = useColor
+	opts.Width = terminalWidth(int(cmd.Int("width")))
+	opts.Wrap = cmd.Bool("wrap")
+	opts.Stat = cmd.Bool("stat")
+	opts.Template = cmd.String("template-string")
+	if cmd.IsSet("template") && cmd.IsSet("template-string") {
+		return opts, errors.New("--template and --template-string cannot be used together")
+	}
+	if path := cmd.String("template"); path != "" {
+		text, err := os.ReadFile(path)
+		if err != nil {
+			return opts, fmt.Errorf("read template: %w", err)
+		}
+		opts.Template = string(text)
+	}
+	for _, kv := range cmd.StringSlice("format-opt") {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok {
+			return opts, fmt.Errorf("format option %q is not key=value", kv)
+		}
+		if opts.Extra == nil {
+			opts.Extra = map[string]string{}
+		}
+		opts.Extra[key] = value
+	}
+	return opts, nil
+}
+
+// colorEnabled resolves --color; "auto" honors NO_COLOR and only colors
+// terminals. A nil out stands for a file given with --output.
+func colorEnabled(mode string, out *os.File) (bool, error) {
+	switch mode {
+	case "always":
+		return true, nil
+	case "never":
+		return false, nil
+	case "auto":
+		if out == nil || os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+			return false, nil
+		}
+		info, err := out.Stat()
+		if err != nil {
+			return false, nil
+		}
+		return info.Mode()&os.ModeCharDevice != 0, nil
+	default:
+		return false, fmt.Errorf("unknown color mode %q", mode)
+	}
+}
+
+func terminalWidth(flagValue int) int {
+	if flagValue > 0 {
+		return flagValue
+	}
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n > 0 {
+		return n
 	}
+	return 0
 }
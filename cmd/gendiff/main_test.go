@@ -0,0 +1,56 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestColorEnabled(t *testing.T) {
+	// /dev/null is a character device, which is all auto checks for a terminal.
+	tty, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tty.Close()
+	file, err := os.Create(filepath.Join(t.TempDir(), "out"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	cases := []struct {
+		name    string
+		mode    string
+		out     *os.File
+		env     map[string]string
+		want    bool
+		wantErr bool
+	}{
+		{name: "always", mode: "always", out: file, want: true},
+		{name: "always with NO_COLOR", mode: "always", out: tty, env: map[string]string{"NO_COLOR": "1"}, want: true},
+		{name: "never", mode: "never", out: tty, want: false},
+		{name: "auto on a terminal", mode: "auto", out: tty, want: true},
+		{name: "auto on a file", mode: "auto", out: file, want: false},
+		{name: "auto with NO_COLOR", mode: "auto", out: tty, env: map[string]string{"NO_COLOR": "1"}, want: false},
+		{name: "auto with TERM=dumb", mode: "auto", out: tty, env: map[string]string{"TERM": "dumb"}, want: false},
+		{name: "auto writing to --output", mode: "auto", out: nil, want: false},
+		{name: "unknown mode", mode: "sometimes", out: tty, wantErr: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("NO_COLOR", "")
+			t.Setenv("TERM", "xterm")
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+			got, err := colorEnabled(tc.mode, tc.out)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("colorEnabled(%q) error = %v, wantErr %v", tc.mode, err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Errorf("colorEnabled(%q) = %v, want %v", tc.mode, got, tc.want)
+			}
+		})
+	}
+}
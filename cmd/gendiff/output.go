@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+
+	urfaveCli "github.com/urfave/cli/v3"
+)
+
+// outputPath is the --output file, or "" for stdout.
+func outputPath(cmd *urfaveCli.Command) string {
+	if path := cmd.String("output"); path != "-" {
+		return path
+	}
+	return ""
+}
+
+// writeOutput streams write to --output, or to stdout when it is unset.
+func writeOutput(cmd *urfaveCli.Command, write func(io.Writer) error) error {
+	path := outputPath(cmd)
+	if path == "" {
+		bw := bufio.NewWriter(os.Stdout)
+		err := write(bw)
+		return errors.Join(err, bw.Flush())
+	}
+	return writeFileAtomic(path, write)
+}
+
+// writeFileAtomic writes to a temporary file next to path and renames it into
+// place only once write succeeded, so readers never see a partial diff and a
+// failed render leaves the previous file untouched.
+func writeFileAtomic(path string, write func(io.Writer) error) (err error) {
+	mode := os.FileMode(0o644)
+	if info, statErr := os.Stat(path); statErr == nil {
+		mode = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	bw := bufio.NewWriter(tmp)
+	if err = write(bw); err != nil {
+		return err
+	}
+	if err = bw.Flush(); err != nil {
+		return err
+	}
+	if err = tmp.Chmod(mode); err != nil {
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
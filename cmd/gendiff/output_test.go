@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	errRender := errors.New("render failed")
+	cases := []struct {
+		name     string
+		existing bool
+		write    func(io.Writer) error
+		wantErr  error
+		want     string
+		wantMode os.FileMode
+	}{
+		{
+			name: "failed render keeps the old file", existing: true,
+			write: func(w io.Writer) error {
+				_, _ = io.WriteString(w, "partial")
+				return errRender
+			},
+			wantErr: errRender, want: "old\n", wantMode: 0o600,
+		},
+		{
+			name: "replaces the file and keeps its mode", existing: true,
+			write: func(w io.Writer) error {
+				_, err := io.WriteString(w, "new\n")
+				return err
+			},
+			want: "new\n", wantMode: 0o600,
+		},
+		{
+			name: "new file",
+			write: func(w io.Writer) error {
+				_, err := io.WriteString(w, "new\n")
+				return err
+			},
+			want: "new\n", wantMode: 0o644,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			p := filepath.Join(dir, "out.diff")
+			if tc.existing {
+				if err := os.WriteFile(p, []byte("old\n"), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := writeFileAtomic(p, tc.write); !errors.Is(err, tc.wantErr) {
+				t.Fatalf("writeFileAtomic() = %v, want %v", err, tc.wantErr)
+			}
+
+			got, err := os.ReadFile(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tc.want {
+				t.Errorf("content = %q, want %q", got, tc.want)
+			}
+			info, err := os.Stat(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if mode := info.Mode().Perm(); mode != tc.wantMode {
+				t.Errorf("mode = %v, want %v", mode, tc.wantMode)
+			}
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 1 {
+				var names []string
+				for _, e := range entries {
+					names = append(names, e.Name())
+				}
+				t.Errorf("dir holds %q, want only out.diff", names)
+			}
+		})
+	}
+}
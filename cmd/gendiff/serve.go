@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"code/server"
+	urfaveCli "github.com/urfave/cli/v3"
+)
+
+const shutdownTimeout = 5 * time.Second
+
+func serveCommand() *urfaveCli.Command {
+	return &urfaveCli.Command{
+		Name:      "serve",
+		Usage:     "serve diffs over HTTP (POST /diff, GET /healthz)",
+		UsageText: "gendiff serve [--addr :8080] [--max-body 1048576]",
+		Flags: []urfaveCli.Flag{
+			&urfaveCli.StringFlag{
+				Name:  "addr",
+				Usage: "listen address",
+				Value: ":8080",
+			},
+			&urfaveCli.Int64Flag{
+				Name:  "max-body",
+				Usage: "maximum request body size in bytes",
+				Value: server.DefaultMaxBodyBytes,
+			},
+		},
+		Action: runServe,
+	}
+}
+
+func runServe(ctx context.Context, cmd *urfaveCli.Command) error {
+	docLimits := limits(cmd)
+	srv := &http.Server{
+		Addr:              cmd.String("addr"),
+		Handler:           server.NewHandler(server.Options{MaxBodyBytes: cmd.Int64("max-body"), Limits: &docLimits}),
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		WriteTimeout:      30 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		fmt.Fprintf(os.Stderr, "gendiff: listening on %s\n", srv.Addr)
+		errCh <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return exitError(err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return exitError(err)
+	}
+	return nil
+}
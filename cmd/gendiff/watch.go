@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"code"
+	"code/formatters"
+	"code/watch"
+	urfaveCli "github.com/urfave/cli/v3"
+)
+
+const clearScreen = "\x1b[H\x1b[2J"
+
+// runWatch re-renders on every settled change until interrupted. Parse errors
+// from half-saved files are shown in place of the diff instead of exiting.
+// With --output the file is rewritten instead and errors go to stderr, leaving
+// the last good diff in place.
+func runWatch(ctx context.Context, cmd *urfaveCli.Command, f1, f2 string) error {
+	opts, err := formatOptions(cmd)
+	if err != nil {
+		return exitError(err)
+	}
+	opts.OldLabel, opts.NewLabel = f1, f2
+	format := cmd.String("format")
+	inputFormat := cmd.String("input-format")
+	lim, dups := limits(cmd), duplicateKeys(cmd)
+
+	render := func() {
+		fmt.Print(clearScreen)
+		fmt.Printf("gendiff --watch %s %s  (%s)\n\n", f1, f2, time.Now().Format(time.TimeOnly))
+
+		res, err := code.Diff(ctx, code.FileSourceAs(f1, inputFormat), code.FileSourceAs(f2, inputFormat), code.WithLimits(lim), code.WithDuplicateKeys(dups))
+		if err != nil {
+			fmt.Printf("error: %v\n", err)
+			return
+		}
+		if err := formatters.WriteContext(ctx, os.Stdout, format, res.Nodes, opts); err != nil {
+			fmt.Printf("error: %v\n", err)
+			return
+		}
+		printWarnings(os.Stdout, format, opts, res.Nodes)
+	}
+	if path := outputPath(cmd); path != "" {
+		render = func() {
+			res, err := code.Diff(ctx, code.FileSourceAs(f1, inputFormat), code.FileSourceAs(f2, inputFormat), code.WithLimits(lim), code.WithDuplicateKeys(dups))
+			if err == nil {
+				err = writeFileAtomic(path, func(w io.Writer) error {
+					return formatters.WriteContext(ctx, w, format, res.Nodes, opts)
+				})
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s error: %v\n", time.Now().Format(time.TimeOnly), err)
+				return
+			}
+			printWarnings(os.Stderr, format, opts, res.Nodes)
+			fmt.Printf("%s wrote %s\n", time.Now().Format(time.TimeOnly), path)
+		}
+	}
+
+	render()
+	err = watch.Watch(ctx, []string{f1, f2}, watch.Options{
+		Interval: cmd.Duration("watch-interval"),
+		Debounce: watch.DefaultDebounce,
+		Poll:     cmd.Bool("watch-poll"),
+	}, render)
+	if err != nil && !errors.Is(err, context.Canceled) {
+		return exitError(err)
+	}
+	return nil
+}
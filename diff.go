@@ -0,0 +1,184 @@
+package code
+
+import (
+	"code/ast"
+	"code/formatters"
+	"code/parsers"
+	"context"
+	"fmt"
+	"io"
+)
+
+// Source is one side of a comparison.
+type Source interface {
+	// Name labels the document in errors and rendered headers.
+	Name() string
+	// Load parses the document with opts, giving up once ctx is done.
+	Load(ctx context.Context, opts parsers.Options) (map[string]any, error)
+}
+
+type fileSource struct {
+	path   string
+	format string
+}
+
+// FileSource reads path and picks the parser by its extension.
+func FileSource(path string) Source {
+	return fileSource{path: path}
+}
+
+// FileSourceAs reads path with the parser registered for format (a name, MIME
+// type or extension); an empty format behaves like FileSource.
+func FileSourceAs(path, format string) Source {
+	return fileSource{path: path, format: format}
+}
+
+func (s fileSource) Name() string { return s.path }
+
+func (s fileSource) Load(ctx context.Context, opts parsers.Options) (map[string]any, error) {
+	return parsers.ParseFileContext(ctx, s.path, s.format, opts)
+}
+
+type bytesSource struct {
+	name   string
+	data   []byte
+	format string
+}
+
+// BytesSource parses data as format ("json", "yaml"); an empty format is
+// inferred from the extension of name.
+func BytesSource(name string, data []byte, format string) Source {
+	return bytesSource{name: name, data: data, format: format}
+}
+
+func (s bytesSource) Name() string { return s.name }
+
+func (s bytesSource) Load(ctx context.Context, opts parsers.Options) (map[string]any, error) {
+	return parsers.ParseContext(ctx, s.data, s.format, s.name, opts)
+}
+
+type readerSource struct {
+	name   string
+	r      io.Reader
+	format string
+}
+
+// ReaderSource reads r to the end on Load; format works as in BytesSource.
+func ReaderSource(name string, r io.Reader, format string) Source {
+	return readerSource{name: name, r: r, format: format}
+}
+
+func (s readerSource) Name() string { return s.name }
+
+func (s readerSource) Load(ctx context.Context, opts parsers.Options) (map[string]any, error) {
+	data, err := parsers.ReadLimited(s.r, opts.MaxBytes)
+	if err != nil {
+		return nil, fmt.Errorf("read %q: %w", s.name, err)
+	}
+	return parsers.ParseContext(ctx, data, s.format, s.name, opts)
+}
+
+type config struct {
+	leftLabel  string
+	rightLabel string
+	limits     parsers.Limits
+	duplicates parsers.DuplicateKeys
+}
+
+type Option func(*config)
+
+// WithLabels overrides the source names used as headers when rendering.
+func WithLabels(left, right string) Option {
+	return func(c *config) {
+		c.leftLabel, c.rightLabel = left, right
+	}
+}
+
+// WithLimits bounds the size and shape of both documents; see parsers.Limits.
+// Without it nothing is limited.
+func WithLimits(limits parsers.Limits) Option {
+	return func(c *config) {
+		c.limits = limits
+	}
+}
+
+// WithDuplicateKeys sets what a key repeated within one object does; see
+// parsers.DuplicateKeys. Under parsers.WarnDuplicates every repeat adds a
+// warning to the node it belongs to, or to its nearest ancestor in the tree.
+func WithDuplicateKeys(mode parsers.DuplicateKeys) Option {
+	return func(c *config) {
+		c.duplicates = mode
+	}
+}
+
+type Result struct {
+	Nodes      []ast.Node
+	LeftLabel  string
+	RightLabel string
+	Changed    bool
+	Summary    ast.Summary
+}
+
+// Diff loads both sources and builds the diff tree; rendering is left to
+// Result.Render or the formatters package.
+func Diff(ctx context.Context, left, right Source, opts ...Option) (*Result, error) {
+	cfg := config{leftLabel: left.Name(), rightLabel: right.Name()}
+	for _, o := range opts {
+		o(&cfg)
+	}
+
+	var warnings []ast.Warning
+	docs := make([]map[string]any, 0, 2)
+	for _, src := range []Source{left, right} {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		var dups []parsers.DuplicateKey
+		doc, err := src.Load(ctx, parsers.Options{
+			Limits:      cfg.limits,
+			Duplicates:  cfg.duplicates,
+			OnDuplicate: func(d parsers.DuplicateKey) { dups = append(dups, d) },
+		})
+		if err != nil {
+			return nil, &SourceError{Source: src.Name(), Err: err}
+		}
+		for _, d := range dups {
+			warnings = append(warnings, ast.Warning{Path: d.Path, Text: d.Warning(src.Name())})
+		}
+		docs = append(docs, doc)
+	}
+
+	nodes, err := ast.BuildDiffContext(ctx, docs[0], docs[1])
+	if err != nil {
+		return nil, err
+	}
+	ast.Annotate(nodes, warnings)
+	return &Result{
+		Nodes:      nodes,
+		LeftLabel:  cfg.leftLabel,
+		RightLabel: cfg.rightLabel,
+		Changed:    ast.HasChanges(nodes),
+		Summary:    ast.Summarize(nodes),
+	}, nil
+}
+
+// Render formats the diff; empty labels in opts default to the source labels.
+func (r *Result) Render(format string, opts formatters.Options) (string, error) {
+	return formatters.RenderWithOptions(format, r.Nodes, r.labeled(opts))
+}
+
+// Write streams the formatted diff to w, with the same label defaults as Render,
+// and stops once ctx is done.
+func (r *Result) Write(ctx context.Context, w io.Writer, format string, opts formatters.Options) error {
+	return formatters.WriteContext(ctx, w, format, r.Nodes, r.labeled(opts))
+}
+
+func (r *Result) labeled(opts formatters.Options) formatters.Options {
+	if opts.OldLabel == "" {
+		opts.OldLabel = r.LeftLabel
+	}
+	if opts.NewLabel == "" {
+		opts.NewLabel = r.RightLabel
+	}
+	return opts
+}
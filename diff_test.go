@@ -0,0 +1,160 @@
+package code
+
+import (
+	"code/ast"
+	"code/formatters"
+	"code/parsers"
+	"context"
+	"errors"
+	"io/fs"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiff_Sources(t *testing.T) {
+	t.Parallel()
+
+	left := FileSource("testdata/fixture/file1.json")
+	right := BytesSource("inline.yaml", []byte("common:\n  setting1: Value 1\n"), "")
+
+	res, err := Diff(context.Background(), left, right)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Changed {
+		t.Fatalf("expected changes")
+	}
+	if res.LeftLabel != "testdata/fixture/file1.json" || res.RightLabel != "inline.yaml" {
+		t.Fatalf("labels = %q, %q", res.LeftLabel, res.RightLabel)
+	}
+	if res.Summary.Removed == 0 {
+		t.Fatalf("summary is empty: %#v", res.Summary)
+	}
+}
+
+func TestDiff_ReaderSourceAndRender(t *testing.T) {
+	t.Parallel()
+
+	left := ReaderSource("stdin", strings.NewReader(`{"a":1}`), "json")
+	right := BytesSource("b", []byte("a: 2"), "yaml")
+
+	res, err := Diff(context.Background(), left, right, WithLabels("old", "new"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := res.Render("unified", formatters.DefaultOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "--- old\n+++ new\n@@ -1,3 +1,3 @@\n {\n-  \"a\": 1\n+  \"a\": 2\n }"
+	if got != want {
+		t.Fatalf("Render:\n got: %q\nwant: %q", got, want)
+	}
+}
+
+func TestDiff_Errors(t *testing.T) {
+	t.Parallel()
+
+	ok := BytesSource("a.json", []byte(`{}`), "")
+
+	if _, err := Diff(context.Background(), ok, BytesSource("noext", []byte(`{}`), "")); err == nil {
+		t.Fatalf("expected error for a source without format")
+	}
+
+	_, err := Diff(context.Background(), ok, BytesSource("bad.json", []byte(`{"a":`), ""))
+	var srcErr *SourceError
+	if !errors.As(err, &srcErr) || srcErr.Source != "bad.json" {
+		t.Fatalf("expected *SourceError for bad.json, got %v", err)
+	}
+	var syntaxErr *SyntaxError
+	if !errors.As(err, &syntaxErr) || !errors.Is(err, ErrSyntax) {
+		t.Fatalf("expected a syntax error, got %v", err)
+	}
+
+	_, err = Diff(context.Background(), FileSource("testdata/missing.json"), ok)
+	if !errors.Is(err, fs.ErrNotExist) || errors.Is(err, ErrSyntax) {
+		t.Fatalf("expected fs.ErrNotExist, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Diff(ctx, ok, ok); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestDiff_WithLimits(t *testing.T) {
+	t.Parallel()
+
+	deep := BytesSource("deep.json", []byte(`{"a":{"b":{"c":1}}}`), "")
+	flat := BytesSource("flat.json", []byte(`{"a":1}`), "")
+
+	if _, err := Diff(context.Background(), flat, deep); err != nil {
+		t.Fatalf("no limits: %v", err)
+	}
+
+	_, err := Diff(context.Background(), flat, deep, WithLimits(parsers.Limits{MaxDepth: 2}))
+	var depthErr *parsers.DepthLimitError
+	if !errors.As(err, &depthErr) || !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("got %v, want *parsers.DepthLimitError", err)
+	}
+
+	_, err = Diff(context.Background(), ReaderSource("big.json", strings.NewReader(`{"a":"xxxxxxxx"}`), ""), flat, WithLimits(parsers.Limits{MaxBytes: 8}))
+	var sizeErr *parsers.SizeLimitError
+	if !errors.As(err, &sizeErr) {
+		t.Fatalf("got %v, want *parsers.SizeLimitError", err)
+	}
+}
+
+func TestDiff_WithDuplicateKeys(t *testing.T) {
+	t.Parallel()
+
+	left := BytesSource("left.json", []byte(`{"a":1,"n":{"b":1,"b":2},"a":3}`), "")
+	right := BytesSource("right.yaml", []byte("a: 3\nn:\n  b: 3\nl: [{x: 1, x: 2}]\n"), "")
+
+	res, err := Diff(context.Background(), left, BytesSource("right.json", []byte(`{"a":3}`), ""))
+	if err != nil {
+		t.Fatalf("allow: %v", err)
+	}
+	for _, n := range res.Nodes {
+		if len(n.Warnings) > 0 {
+			t.Fatalf("allow: unexpected warnings on %q", n.Key)
+		}
+	}
+	// YAML forbids repeated keys, so allowing them changes nothing there.
+	if _, err := Diff(context.Background(), left, right); err == nil {
+		t.Fatalf("allow: repeated YAML key accepted")
+	}
+
+	res, err = Diff(context.Background(), left, right, WithDuplicateKeys(parsers.WarnDuplicates))
+	if err != nil {
+		t.Fatalf("warn: %v", err)
+	}
+	want := map[string][]string{
+		"a":   {`duplicate key "a" at left.json:1:26, first at 1:2`},
+		"n.b": {`duplicate key "n.b" at left.json:1:19, first at 1:13`},
+		"l":   {`duplicate key "l[0].x" at right.yaml:4:12, first at 4:6`},
+	}
+	got := map[string][]string{}
+	var collect func(nodes []ast.Node, prefix string)
+	collect = func(nodes []ast.Node, prefix string) {
+		for _, n := range nodes {
+			if len(n.Warnings) > 0 {
+				got[prefix+n.Key] = n.Warnings
+			}
+			collect(n.Children, prefix+n.Key+".")
+		}
+	}
+	collect(res.Nodes, "")
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("warnings = %v, want %v", got, want)
+	}
+
+	_, err = Diff(context.Background(), left, right, WithDuplicateKeys(parsers.RejectDuplicates))
+	var dupErr *DuplicateKeyError
+	if !errors.As(err, &dupErr) || !errors.Is(err, ErrDuplicateKey) || dupErr.File != "left.json" {
+		t.Fatalf("strict: got %v, want *DuplicateKeyError for left.json", err)
+	}
+}
@@ -0,0 +1,258 @@
+package code
+
+import (
+	"code/ast"
+	"code/parsers"
+	"context"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+type FileStatus string
+
+const (
+	OnlyLeft    FileStatus = "only-left"
+	OnlyRight   FileStatus = "only-right"
+	Compared    FileStatus = "compared"
+	Unsupported FileStatus = "unsupported"
+	Failed      FileStatus = "failed"
+)
+
+type DirOptions struct {
+	// Include and Exclude are globs over slash-separated relative paths. "**"
+	// crosses directories; a pattern without "/" matches the base name.
+	Include []string
+	Exclude []string
+	// Workers bounds concurrent file diffs; zero means runtime.NumCPU().
+	Workers int
+	// InputFormat, when set, parses every file with that parser, as
+	// FileSourceAs does, so no file is Unsupported.
+	InputFormat string
+	// Limits applies to every file; a file over a limit is reported as Failed.
+	Limits parsers.Limits
+	// Duplicates applies to every file, as in WithDuplicateKeys.
+	Duplicates parsers.DuplicateKeys
+}
+
+type FileResult struct {
+	Path   string
+	Left   string
+	Right  string
+	Status FileStatus
+	Nodes  []ast.Node
+	Err    error
+}
+
+func (r FileResult) Changed() bool {
+	return r.Status == OnlyLeft || r.Status == OnlyRight || (r.Status == Compared && ast.HasChanges(r.Nodes))
+}
+
+type DirSummary struct {
+	Compared  int
+	Differ    int
+	OnlyLeft  int
+	OnlyRight int
+	// Skipped counts pairs left undiffed for an Unsupported extension.
+	Skipped int
+	Failed  int
+}
+
+func SummarizeDirs(results []FileResult) DirSummary {
+	var s DirSummary
+	for _, r := range results {
+		s.Add(r)
+	}
+	return s
+}
+
+func (s *DirSummary) Add(r FileResult) {
+	switch r.Status {
+	case Compared:
+		s.Compared++
+		if ast.HasChanges(r.Nodes) {
+			s.Differ++
+		}
+	case OnlyLeft:
+		s.OnlyLeft++
+	case OnlyRight:
+		s.OnlyRight++
+	case Unsupported:
+		s.Skipped++
+	case Failed:
+		s.Failed++
+	}
+}
+
+// DiffDirs walks both trees, pairs files by relative path and diffs every pair
+// with a supported extension; the others are reported as Unsupported.
+// Per-file failures are recorded in the result.
+func DiffDirs(dir1, dir2 string, opts DirOptions) ([]FileResult, error) {
+	var results []FileResult
+	err := WalkDirs(context.Background(), dir1, dir2, opts, func(r FileResult) {
+		results = append(results, r)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// WalkDirs is the streaming form of DiffDirs: files are diffed concurrently
+// and emit receives them sorted by relative path as soon as they are ready.
+func WalkDirs(ctx context.Context, dir1, dir2 string, opts DirOptions, emit func(FileResult)) error {
+	filter, err := newPathFilter(opts)
+	if err != nil {
+		return err
+	}
+
+	left, err := listFiles(dir1, filter)
+	if err != nil {
+		return err
+	}
+	right, err := listFiles(dir2, filter)
+	if err != nil {
+		return err
+	}
+
+	paths := make([]string, 0, len(left)+len(right))
+	for p := range left {
+		paths = append(paths, p)
+	}
+	for p := range right {
+		if _, ok := left[p]; !ok {
+			paths = append(paths, p)
+		}
+	}
+	sort.Strings(paths)
+
+	return forEachOrdered(ctx, len(paths), opts.Workers, func(i int) FileResult {
+		p := paths[i]
+		r := FileResult{
+			Path:  p,
+			Left:  filepath.Join(dir1, filepath.FromSlash(p)),
+			Right: filepath.Join(dir2, filepath.FromSlash(p)),
+		}
+		_, inLeft := left[p]
+		_, inRight := right[p]
+
+		switch {
+		case !inRight:
+			r.Status = OnlyLeft
+		case !inLeft:
+			r.Status = OnlyRight
+		case opts.InputFormat == "" && !parsers.Supported(p):
+			r.Status = Unsupported
+		default:
+			var res *Result
+			res, r.Err = Diff(ctx, FileSourceAs(r.Left, opts.InputFormat), FileSourceAs(r.Right, opts.InputFormat), WithLimits(opts.Limits), WithDuplicateKeys(opts.Duplicates))
+			if res != nil {
+				r.Nodes = res.Nodes
+			}
+			r.Status = Compared
+			if r.Err != nil {
+				r.Status = Failed
+			}
+		}
+		return r
+	}, emit)
+}
+
+func listFiles(root string, filter pathFilter) (map[string]struct{}, error) {
+	files := map[string]struct{}{}
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if filter.allows(rel) {
+			files[rel] = struct{}{}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walk %q: %w", root, err)
+	}
+	return files, nil
+}
+
+type pathFilter struct {
+	include []*regexp.Regexp
+	exclude []*regexp.Regexp
+}
+
+func newPathFilter(opts DirOptions) (pathFilter, error) {
+	var f pathFilter
+	var err error
+	if f.include, err = compileGlobs(opts.Include); err != nil {
+		return f, err
+	}
+	if f.exclude, err = compileGlobs(opts.Exclude); err != nil {
+		return f, err
+	}
+	return f, nil
+}
+
+func (f pathFilter) allows(rel string) bool {
+	if len(f.include) > 0 && !matchAny(f.include, rel) {
+		return false
+	}
+	return !matchAny(f.exclude, rel)
+}
+
+func matchAny(globs []*regexp.Regexp, rel string) bool {
+	for _, g := range globs {
+		if g.MatchString(rel) {
+			return true
+		}
+	}
+	return false
+}
+
+func compileGlobs(patterns []string) ([]*regexp.Regexp, error) {
+	out := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		re, err := regexp.Compile(globToRegexp(p))
+		if err != nil {
+			return nil, fmt.Errorf("bad glob %q: %w", p, err)
+		}
+		out = append(out, re)
+	}
+	return out, nil
+}
+
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	b.WriteString("^")
+	if !strings.Contains(glob, "/") {
+		b.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; {
+		case strings.HasPrefix(glob[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return b.String()
+}
@@ -0,0 +1,153 @@
+package code
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTree(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for rel, body := range files {
+		p := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestDiffDirs_PairsByRelativePath(t *testing.T) {
+	t.Parallel()
+
+	left, right := t.TempDir(), t.TempDir()
+	writeTree(t, left, map[string]string{
+		"dev/app.json":  `{"a":1}`,
+		"dev/same.yaml": "a: 1\n",
+		"prod/old.json": `{}`,
+		"notes.txt":     "x",
+		"broken.json":   `{`,
+	})
+	writeTree(t, right, map[string]string{
+		"dev/app.json":  `{"a":2}`,
+		"dev/same.yaml": "a: 1\n",
+		"prod/new.json": `{}`,
+		"notes.txt":     "y",
+		"broken.json":   `{}`,
+	})
+
+	results, err := DiffDirs(left, right, DirOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]FileStatus{
+		"broken.json":   Failed,
+		"dev/app.json":  Compared,
+		"dev/same.yaml": Compared,
+		"notes.txt":     Unsupported,
+		"prod/new.json": OnlyRight,
+		"prod/old.json": OnlyLeft,
+	}
+	if len(results) != len(want) {
+		t.Fatalf("got %d results, want %d: %#v", len(results), len(want), results)
+	}
+	for i, r := range results {
+		if i > 0 && results[i-1].Path >= r.Path {
+			t.Fatalf("results are not sorted: %q before %q", results[i-1].Path, r.Path)
+		}
+		if want[r.Path] != r.Status {
+			t.Fatalf("%s: status %q, want %q", r.Path, r.Status, want[r.Path])
+		}
+	}
+
+	s := SummarizeDirs(results)
+	if s != (DirSummary{Compared: 2, Differ: 1, OnlyLeft: 1, OnlyRight: 1, Skipped: 1, Failed: 1}) {
+		t.Fatalf("unexpected summary: %#v", s)
+	}
+}
+
+func TestDiffDirs_InputFormat(t *testing.T) {
+	t.Parallel()
+
+	left, right := t.TempDir(), t.TempDir()
+	writeTree(t, left, map[string]string{"app.conf": `{"a":1}`})
+	writeTree(t, right, map[string]string{"app.conf": `{"a":2}`})
+
+	results, err := DiffDirs(left, right, DirOptions{InputFormat: "json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Status != Compared || !results[0].Changed() {
+		t.Fatalf("results = %#v, want app.conf compared as JSON", results)
+	}
+}
+
+func TestDiffDirs_IncludeExclude(t *testing.T) {
+	t.Parallel()
+
+	left, right := t.TempDir(), t.TempDir()
+	files := map[string]string{
+		"a/one.json":     `{}`,
+		"a/b/two.yaml":   "x: 1\n",
+		"a/b/skip.json":  `{}`,
+		"top.yaml":       "x: 1\n",
+		"vendor/x.json":  `{}`,
+		"vendor/y/z.yml": "x: 1\n",
+	}
+	writeTree(t, left, files)
+	writeTree(t, right, files)
+
+	results, err := DiffDirs(left, right, DirOptions{
+		Include: []string{"a/**", "*.yaml"},
+		Exclude: []string{"skip.json"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, r := range results {
+		got = append(got, r.Path)
+	}
+	want := []string{"a/b/two.yaml", "a/one.json", "top.yaml"}
+	if len(got) != len(want) {
+		t.Fatalf("paths = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("paths = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestGlobToRegexp(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		glob  string
+		path  string
+		match bool
+	}{
+		{"*.json", "a/b/c.json", true},
+		{"*.json", "c.yaml", false},
+		{"dev/*.json", "dev/a.json", true},
+		{"dev/*.json", "dev/x/a.json", false},
+		{"dev/**/*.json", "dev/a.json", true},
+		{"dev/**/*.json", "dev/x/y/a.json", true},
+		{"**", "anything/at/all", true},
+		{"a?.yaml", "ab.yaml", true},
+		{"a.yaml", "aXyaml", false},
+	}
+	for _, tc := range cases {
+		res, err := compileGlobs([]string{tc.glob})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := res[0].MatchString(tc.path); got != tc.match {
+			t.Fatalf("glob %q on %q = %v, want %v", tc.glob, tc.path, got, tc.match)
+		}
+	}
+}
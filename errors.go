@@ -0,0 +1,38 @@
+package code
+
+import (
+	"code/formatters"
+	"code/parsers"
+)
+
+// The sentinels and error types of parsers and formatters, re-exported so
+// callers of this package can classify a Diff or Render error with errors.Is
+// and errors.As alone. A missing or unreadable file still matches fs.ErrNotExist
+// and friends.
+var (
+	ErrUnsupportedFormat = parsers.ErrUnsupportedFormat
+	ErrSyntax            = parsers.ErrSyntax
+	ErrLimitExceeded     = parsers.ErrLimitExceeded
+	ErrUnknownFormatter  = formatters.ErrUnknownFormatter
+	ErrDuplicateKey      = parsers.ErrDuplicateKey
+)
+
+type (
+	UnsupportedFormatError = parsers.UnsupportedFormatError
+	SyntaxError            = parsers.SyntaxError
+	UnknownFormatterError  = formatters.UnknownFormatterError
+	DuplicateKeyError      = parsers.DuplicateKeyError
+)
+
+// SourceError reports which side of a comparison could not be loaded.
+type SourceError struct {
+	// Source is the Name of the failing Source.
+	Source string
+	Err    error
+}
+
+func (e *SourceError) Error() string {
+	return "parse files: " + e.Err.Error()
+}
+
+func (e *SourceError) Unwrap() error { return e.Err }
@@ -0,0 +1,73 @@
+package formatters
+
+import (
+	"code/ast"
+	"code/formatters/html"
+	"code/formatters/json"
+	"code/formatters/jsonpatch"
+	"code/formatters/markdown"
+	"code/formatters/mergepatch"
+	"code/formatters/plain"
+	"code/formatters/sidebyside"
+	"code/formatters/stylish"
+	"code/formatters/summary"
+	"code/formatters/template"
+	"code/formatters/unified"
+	"code/formatters/yaml"
+	"context"
+	"io"
+)
+
+// nodesOnly adapts formatters that take no options.
+func nodesOnly(write func(io.Writer, []ast.Node) error) Formatter {
+	return FormatterFunc(func(w io.Writer, nodes []ast.Node, _ Options) error {
+		return write(w, nodes)
+	})
+}
+
+// unifiedFormatter checks ctx while it computes the edit script, which can take
+// long before anything is written.
+type unifiedFormatter struct{}
+
+func (f unifiedFormatter) Write(w io.Writer, nodes []ast.Node, opts Options) error {
+	return f.WriteContext(context.Background(), w, nodes, opts)
+}
+
+func (unifiedFormatter) WriteContext(ctx context.Context, w io.Writer, nodes []ast.Node, opts Options) error {
+	return unified.WriteContext(ctx, w, nodes, unified.Options{
+		Context:  opts.Context,
+		Syntax:   opts.Syntax,
+		OldLabel: opts.OldLabel,
+		NewLabel: opts.NewLabel,
+	})
+}
+
+func init() {
+	Register("stylish", FormatterFunc(func(w io.Writer, nodes []ast.Node, opts Options) error {
+		return stylish.Write(w, nodes, stylish.Options{Color: opts.Color})
+	}))
+	Register("plain", FormatterFunc(func(w io.Writer, nodes []ast.Node, opts Options) error {
+		return plain.Write(w, nodes, plain.Options{Color: opts.Color})
+	}))
+	Register("json", nodesOnly(json.Write))
+	Register("jsonpatch", nodesOnly(jsonpatch.Write))
+	Register("mergepatch", nodesOnly(mergepatch.Write))
+	Register("unified", unifiedFormatter{})
+	Register("side-by-side", FormatterFunc(func(w io.Writer, nodes []ast.Node, opts Options) error {
+		return sidebyside.Write(w, nodes, sidebyside.Options{Width: opts.Width, Wrap: opts.Wrap})
+	}))
+	Register("html", FormatterFunc(func(w io.Writer, nodes []ast.Node, opts Options) error {
+		return html.Write(w, nodes, html.Options{OldLabel: opts.OldLabel, NewLabel: opts.NewLabel})
+	}))
+	Register("markdown", nodesOnly(markdown.Write))
+	Register("yaml", nodesOnly(yaml.Write))
+	Register("yaml-compact", nodesOnly(yaml.WriteCompact))
+	Register("summary", nodesOnly(summary.Write))
+	Register("template", FormatterFunc(func(w io.Writer, nodes []ast.Node, opts Options) error {
+		return template.Write(w, nodes, template.Options{
+			Text:     opts.Template,
+			OldLabel: opts.OldLabel,
+			NewLabel: opts.NewLabel,
+		})
+	}))
+}
@@ -0,0 +1,34 @@
+package color
+
+import "code/ast"
+
+const (
+	reset  = "\x1b[0m"
+	Red    = "\x1b[31m"
+	Green  = "\x1b[32m"
+	Yellow = "\x1b[33m"
+	Dim    = "\x1b[2m"
+)
+
+func ForAction(a ast.NodeType) string {
+	switch a {
+	case ast.Removed:
+		return Red
+	case ast.Added:
+		return Green
+	case ast.Updated:
+		return Yellow
+	case ast.Unchanged:
+		return Dim
+	default:
+		return ""
+	}
+}
+
+// Paint wraps s in the given escape sequence when enabled is true.
+func Paint(enabled bool, code, s string) string {
+	if !enabled || code == "" {
+		return s
+	}
+	return code + s + reset
+}
@@ -0,0 +1,33 @@
+package color
+
+import (
+	"code/ast"
+	"testing"
+)
+
+func TestPaint(t *testing.T) {
+	if got := Paint(false, Red, "x"); got != "x" {
+		t.Fatalf("Paint(disabled) = %q, want %q", got, "x")
+	}
+	if got := Paint(true, "", "x"); got != "x" {
+		t.Fatalf("Paint(no code) = %q, want %q", got, "x")
+	}
+	if got := Paint(true, Red, "x"); got != "\x1b[31mx\x1b[0m" {
+		t.Fatalf("Paint(red) = %q", got)
+	}
+}
+
+func TestForAction(t *testing.T) {
+	cases := map[ast.NodeType]string{
+		ast.Removed:   Red,
+		ast.Added:     Green,
+		ast.Updated:   Yellow,
+		ast.Unchanged: Dim,
+		ast.Nested:    "",
+	}
+	for action, want := range cases {
+		if got := ForAction(action); got != want {
+			t.Fatalf("ForAction(%v) = %q, want %q", action, got, want)
+		}
+	}
+}
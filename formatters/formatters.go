@@ -2,21 +2,231 @@ package formatters
 
 import (
 	"code/ast"
-	"code/formatters/json"
-	"code/formatters/plain"
-	"code/formatters/stylish"
+	"code/formatters/summary"
+	"code/formatters/unified"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
 )
 
-func Render(format string, nodes []ast.Node) (string, error) {
+const DefaultFormat = "stylish"
+
+type Options struct {
+	Context  int
+	Syntax   string
+	OldLabel string
+	NewLabel string
+	Color    bool
+	Width    int
+	Wrap     bool
+	// Stat replaces the diff with summary statistics, as json for the json format.
+	Stat bool
+	// Template is the text/template source for the template format.
+	Template string
+	// Extra carries formatter-specific settings (--format-opt key=value) for
+	// formatters registered outside this package.
+	Extra map[string]string
+}
+
+// Formatter writes a rendered diff to w as it goes rather than building the
+// whole output first. A trailing newline is optional; Write adds one.
+type Formatter interface {
+	Write(w io.Writer, nodes []ast.Node, opts Options) error
+}
+
+// FormatterFunc adapts a plain function to Formatter.
+type FormatterFunc func(w io.Writer, nodes []ast.Node, opts Options) error
+
+func (f FormatterFunc) Write(w io.Writer, nodes []ast.Node, opts Options) error {
+	return f(w, nodes, opts)
+}
+
+// ContextFormatter is a Formatter that can spend a while working before its
+// first write. WriteContext uses it so such formatters notice cancellation
+// then too, not only when they next write.
+type ContextFormatter interface {
+	Formatter
+	WriteContext(ctx context.Context, w io.Writer, nodes []ast.Node, opts Options) error
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Formatter{}
+	names      []string
+)
+
+// Register makes a formatter available under name. Like database/sql drivers,
+// it panics when name is already taken or f is nil.
+func Register(name string, f Formatter) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if f == nil {
+		panic("formatters: Register formatter is nil")
+	}
+	if _, dup := registry[name]; dup {
+		panic("formatters: Register called twice for " + name)
+	}
+	registry[name] = f
+	names = append(names, name)
+}
+
+func Lookup(name string) (Formatter, bool) {
+	if name == "" {
+		name = DefaultFormat
+	}
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	f, ok := registry[name]
+	return f, ok
+}
+
+// Names lists registered formats, built-ins first in registration order.
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	return append([]string(nil), names...)
+}
+
+// ErrUnknownFormatter matches *UnknownFormatterError.
+var ErrUnknownFormatter = errors.New("unknown formatter")
+
+// UnknownFormatterError reports a format name nothing is registered under.
+type UnknownFormatterError struct {
+	Name string
+	// Known lists the registered formats, sorted.
+	Known []string
+}
+
+func (e *UnknownFormatterError) Error() string {
+	return fmt.Sprintf("unknown format %q (known: %s)", e.Name, strings.Join(e.Known, ", "))
+}
+
+func (e *UnknownFormatterError) Is(target error) bool {
+	return target == ErrUnknownFormatter
+}
+
+func unknownFormatter(name string) error {
+	known := Names()
+	sort.Strings(known)
+	return &UnknownFormatterError{Name: name, Known: known}
+}
+
+func Validate(name string) error {
+	if _, ok := Lookup(name); !ok {
+		return unknownFormatter(name)
+	}
+	return nil
+}
+
+// ShowsWarnings reports whether format renders ast.Node.Warnings next to
+// their nodes under opts; the summary opts.Stat prints never does. Callers
+// should surface warnings some other way for the rest, including every
+// formatter registered outside this package.
+func ShowsWarnings(format string, opts Options) bool {
+	if opts.Stat {
+		return false
+	}
 	switch format {
-	case "", "stylish":
-		return stylish.Render(nodes)
-	case "plain":
-		return plain.Render(nodes)
-	case "json":
-		return json.Render(nodes)
-	default:
-		return "", fmt.Errorf("unknown format %q", format)
+	case "", "stylish", "plain", "json", "yaml":
+		return true
+	}
+	return false
+}
+
+func DefaultOptions() Options {
+	return Options{Context: unified.DefaultContext}
+}
+
+func Render(format string, nodes []ast.Node) (string, error) {
+	return RenderWithOptions(format, nodes, DefaultOptions())
+}
+
+// RenderWithOptions is Write into a string, without the trailing newline.
+func RenderWithOptions(format string, nodes []ast.Node, opts Options) (string, error) {
+	var b strings.Builder
+	if err := Write(&b, format, nodes, opts); err != nil {
+		return "", err
+	}
+	return strings.TrimRight(b.String(), "\n"), nil
+}
+
+// Write renders nodes in format to w, ending with a newline. An unknown format
+// is reported before anything is written; a formatter failing part way may
+// leave partial output in w.
+func Write(w io.Writer, format string, nodes []ast.Node, opts Options) error {
+	return WriteContext(context.Background(), w, format, nodes, opts)
+}
+
+// WriteContext is Write that stops at the formatter's next write to w once ctx
+// is done, returning ctx.Err(). A ContextFormatter also gets ctx itself.
+func WriteContext(ctx context.Context, w io.Writer, format string, nodes []ast.Node, opts Options) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	w = ctxWriter{ctx: ctx, w: w}
+
+	var f Formatter = FormatterFunc(func(w io.Writer, nodes []ast.Node, _ Options) error {
+		if format == "json" {
+			return summary.WriteJSON(w, nodes)
+		}
+		return summary.Write(w, nodes)
+	})
+	if !opts.Stat {
+		var ok bool
+		if f, ok = Lookup(format); !ok {
+			return unknownFormatter(format)
+		}
+	}
+
+	lw := &lastByteWriter{w: w}
+	var err error
+	if cf, ok := f.(ContextFormatter); ok {
+		err = cf.WriteContext(ctx, lw, nodes, opts)
+	} else {
+		err = f.Write(lw, nodes, opts)
+	}
+	if err != nil {
+		return err
+	}
+	if lw.last != '\n' {
+		_, err := io.WriteString(w, "\n")
+		return err
+	}
+	return nil
+}
+
+// ctxWriter fails writes once its context is done. Formatters buffer their
+// output, so cancellation is noticed every few KiB rather than per line.
+type ctxWriter struct {
+	ctx context.Context
+	w   io.Writer
+}
+
+func (cw ctxWriter) Write(p []byte) (int, error) {
+	if err := cw.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return cw.w.Write(p)
+}
+
+// lastByteWriter remembers the last byte written so Write knows whether the
+// output still needs its final newline.
+type lastByteWriter struct {
+	w    io.Writer
+	last byte
+}
+
+func (lw *lastByteWriter) Write(p []byte) (int, error) {
+	n, err := lw.w.Write(p)
+	if n > 0 {
+		lw.last = p[n-1]
 	}
+	return n, err
 }
@@ -0,0 +1,136 @@
+package formatters
+
+import (
+	"code/ast"
+	"context"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestBuiltinsRegistered(t *testing.T) {
+	for _, name := range []string{"stylish", "plain", "json", "unified", "summary"} {
+		if _, ok := Lookup(name); !ok {
+			t.Fatalf("built-in %q is not registered", name)
+		}
+	}
+	if got := Names()[0]; got != DefaultFormat {
+		t.Fatalf("Names()[0] = %q, want %q", got, DefaultFormat)
+	}
+	if _, ok := Lookup(""); !ok {
+		t.Fatalf("empty format must resolve to %q", DefaultFormat)
+	}
+}
+
+// registerForTest registers f under name until t ends, so the test can run
+// again under -count and other tests see only the built-ins.
+func registerForTest(t *testing.T, name string, f Formatter) {
+	t.Helper()
+	Register(name, f)
+	t.Cleanup(func() {
+		registryMu.Lock()
+		defer registryMu.Unlock()
+		delete(registry, name)
+		names = slices.DeleteFunc(names, func(n string) bool { return n == name })
+	})
+}
+
+func TestRegister_CustomFormatterWithExtra(t *testing.T) {
+	registerForTest(t, "test-count", FormatterFunc(func(w io.Writer, nodes []ast.Node, opts Options) error {
+		_, err := io.WriteString(w, opts.Extra["prefix"]+strings.Repeat("*", len(nodes)))
+		return err
+	}))
+
+	opts := DefaultOptions()
+	opts.Extra = map[string]string{"prefix": "n="}
+	got, err := RenderWithOptions("test-count", []ast.Node{{Key: "a"}, {Key: "b"}}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "n=**" {
+		t.Fatalf("custom formatter output = %q", got)
+	}
+	if err := Validate("test-count"); err != nil {
+		t.Fatalf("Validate(registered) = %v", err)
+	}
+}
+
+func TestRegister_DuplicatePanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expected panic on duplicate registration")
+		}
+	}()
+	Register("stylish", FormatterFunc(func(io.Writer, []ast.Node, Options) error { return nil }))
+}
+
+func TestRender_Unknown(t *testing.T) {
+	_, err := Render("nope", nil)
+	var unknown *UnknownFormatterError
+	if !errors.As(err, &unknown) || unknown.Name != "nope" || !errors.Is(err, ErrUnknownFormatter) {
+		t.Fatalf("Render(unknown) = %v, want *UnknownFormatterError", err)
+	}
+	if err := Validate("nope"); err == nil || !strings.Contains(err.Error(), "stylish") {
+		t.Fatalf("Validate(unknown) = %v, want error listing known formats", err)
+	}
+}
+
+func TestWrite_MatchesRenderWithOptions(t *testing.T) {
+	nodes := ast.BuildDiff(
+		map[string]any{"a": 1.0, "n": map[string]any{"b": "x", "c": []any{1.0}}, "gone": true},
+		map[string]any{"a": 2.0, "n": map[string]any{"b": "x", "d": false}, "new": map[string]any{"k": "v"}},
+	)
+	opts := DefaultOptions()
+	opts.Template = "{{range changes .Nodes}}{{.Path}}\n{{end}}"
+
+	for _, name := range Names() {
+		rendered, err := RenderWithOptions(name, nodes, opts)
+		if err != nil {
+			t.Fatalf("%s: RenderWithOptions: %v", name, err)
+		}
+		var b strings.Builder
+		if err := Write(&b, name, nodes, opts); err != nil {
+			t.Fatalf("%s: Write: %v", name, err)
+		}
+		if !strings.HasSuffix(b.String(), "\n") || strings.TrimRight(b.String(), "\n") != rendered {
+			t.Errorf("%s: Write output differs from RenderWithOptions:\n%q\n%q", name, b.String(), rendered)
+		}
+	}
+}
+
+func TestShowsWarnings(t *testing.T) {
+	nodes := []ast.Node{
+		{Key: "a", Action: ast.Updated, OldVal: 1.0, NewVal: 2.0, Warnings: []string{"repeated-key-note"}},
+	}
+	opts := DefaultOptions()
+	opts.Template = "{{range changes .Nodes}}{{.Path}}\n{{end}}"
+
+	for _, stat := range []bool{false, true} {
+		opts.Stat = stat
+		for _, name := range Names() {
+			out, err := RenderWithOptions(name, nodes, opts)
+			if err != nil {
+				t.Fatalf("%s: RenderWithOptions: %v", name, err)
+			}
+			if got, want := ShowsWarnings(name, opts), strings.Contains(out, "repeated-key-note"); got != want {
+				t.Errorf("ShowsWarnings(%q, Stat: %v) = %v, but the output shows the warning: %v", name, stat, got, want)
+			}
+		}
+	}
+	if ShowsWarnings("custom", DefaultOptions()) {
+		t.Errorf("ShowsWarnings(%q) = true for a format it does not know", "custom")
+	}
+}
+
+func TestWriteContext_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var b strings.Builder
+	err := WriteContext(ctx, &b, "stylish", []ast.Node{{Key: "a", Action: ast.Added, NewVal: 1}}, DefaultOptions())
+	if !errors.Is(err, context.Canceled) || b.Len() != 0 {
+		t.Fatalf("got %v with %q written, want context.Canceled and no output", err, b.String())
+	}
+}
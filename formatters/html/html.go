@@ -0,0 +1,90 @@
+package html
+
+import (
+	"code/ast"
+	"code/formatters/plain"
+	"code/formatters/stylish"
+	_ "embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"strings"
+)
+
+//go:embed report.html.tmpl
+var reportTemplate string
+
+var report = htmltemplate.Must(htmltemplate.New("report").Parse(strings.TrimRight(reportTemplate, "\n")))
+
+type Options struct {
+	OldLabel string
+	NewLabel string
+}
+
+type viewNode struct {
+	Key        string
+	Path       string
+	Action     string
+	Old        string
+	New        string
+	HasChanges bool
+	HasRemoved bool
+	Children   []viewNode
+}
+
+func Write(w io.Writer, nodes []ast.Node, opts Options) error {
+	data := struct {
+		OldLabel string
+		NewLabel string
+		Counts   ast.Summary
+		Nodes    []viewNode
+	}{
+		OldLabel: labelOr(opts.OldLabel, "file1"),
+		NewLabel: labelOr(opts.NewLabel, "file2"),
+		Counts:   ast.Summarize(nodes),
+		Nodes:    toView(nodes, ""),
+	}
+
+	if err := report.Execute(w, data); err != nil {
+		return fmt.Errorf("execute html template: %w", err)
+	}
+	return nil
+}
+
+func toView(nodes []ast.Node, parentPath string) []viewNode {
+	res := make([]viewNode, 0, len(nodes))
+
+	for _, n := range nodes {
+		v := viewNode{Key: n.Key, Path: plain.JoinPath(parentPath, n.Key), Action: string(n.Action)}
+
+		switch n.Action {
+		case ast.Nested:
+			v.Children = toView(n.Children, v.Path)
+			for _, c := range v.Children {
+				leafChanged := c.Action != string(ast.Unchanged) && c.Action != string(ast.Nested)
+				v.HasChanges = v.HasChanges || leafChanged || c.HasChanges
+				v.HasRemoved = v.HasRemoved || c.Action == string(ast.Removed) || c.HasRemoved
+			}
+		case ast.Added:
+			v.New = stylish.Stringify(n.NewVal, 1)
+		case ast.Removed:
+			v.Old = stylish.Stringify(n.OldVal, 1)
+		case ast.Updated:
+			v.Old = stylish.Stringify(n.OldVal, 1)
+			v.New = stylish.Stringify(n.NewVal, 1)
+		case ast.Unchanged:
+			v.Old = stylish.Stringify(n.OldVal, 1)
+		}
+
+		res = append(res, v)
+	}
+
+	return res
+}
+
+func labelOr(label, fallback string) string {
+	if label == "" {
+		return fallback
+	}
+	return label
+}
@@ -0,0 +1,68 @@
+package html
+
+import (
+	"code/ast"
+	"strings"
+	"testing"
+)
+
+func TestWrite_Report(t *testing.T) {
+	nodes := []ast.Node{
+		{Key: "a", Action: ast.Added, NewVal: "<script>alert(1)</script>"},
+		{
+			Key:    "group",
+			Action: ast.Nested,
+			Children: []ast.Node{
+				{Key: "b", Action: ast.Removed, OldVal: 1},
+				{Key: "c", Action: ast.Updated, OldVal: true, NewVal: false},
+			},
+		},
+		{
+			Key:    "quiet",
+			Action: ast.Nested,
+			Children: []ast.Node{
+				{Key: "d", Action: ast.Unchanged, OldVal: "x"},
+			},
+		},
+	}
+
+	var b strings.Builder
+	if err := Write(&b, nodes, Options{OldLabel: "old.json", NewLabel: "new.json"}); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+	got := b.String()
+
+	for _, want := range []string{
+		"<!DOCTYPE html>",
+		"<style>",
+		"<script>\ndocument.querySelectorAll",
+		"old.json → new.json",
+		"added: 1",
+		"removed: 1",
+		"updated: 1",
+		"unchanged: 1",
+		`<li class="nested has-changes has-removed"><details open><summary>group</summary>`,
+		`<li class="nested"><details open><summary>quiet</summary>`,
+		`<li class="leaf removed" title="group.b">`,
+		"&lt;script&gt;alert(1)&lt;/script&gt;",
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("report does not contain %q:\n%s", want, got)
+		}
+	}
+
+	if strings.Contains(got, "<script>alert(1)") {
+		t.Fatalf("value was not escaped:\n%s", got)
+	}
+}
+
+func TestWrite_DefaultLabels(t *testing.T) {
+	var b strings.Builder
+	if err := Write(&b, nil, Options{}); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+	got := b.String()
+	if !strings.Contains(got, "<title>gendiff: file1 → file2</title>") {
+		t.Fatalf("unexpected title:\n%s", got)
+	}
+}
@@ -1,38 +1,62 @@
 package json
 
 import (
+	"bufio"
 	"code/ast"
 	stdjson "encoding/json"
 	"fmt"
+	"io"
+	"strings"
 )
 
 func Render(nodes []ast.Node) (string, error) {
-	j := toJSONNodes(nodes)
-
-	payload := map[string]any{
-		"diff": j,
+	var b strings.Builder
+	if err := Write(&b, nodes); err != nil {
+		return "", err
 	}
+	return strings.TrimRight(b.String(), "\n"), nil
+}
 
-	data, err := stdjson.MarshalIndent(payload, "", "  ")
-	if err != nil {
-		return "", fmt.Errorf("marshal json: %w", err)
+// Write streams the {"diff": [...]} document one top-level node at a time, so
+// only the largest section is ever marshaled in one piece. The bytes match
+// json.MarshalIndent of the whole payload with a two-space indent.
+func Write(w io.Writer, nodes []ast.Node) error {
+	bw := bufio.NewWriter(w)
+	if len(nodes) == 0 {
+		_, _ = bw.WriteString("{\n  \"diff\": []\n}\n")
+		return bw.Flush()
 	}
 
-	return string(data), nil
+	_, _ = bw.WriteString("{\n  \"diff\": [")
+	for i, n := range nodes {
+		data, err := stdjson.MarshalIndent(ToJSONNodes([]ast.Node{n})[0], "    ", "  ")
+		if err != nil {
+			return fmt.Errorf("marshal json: %w", err)
+		}
+		if i > 0 {
+			_, _ = bw.WriteString(",")
+		}
+		_, _ = bw.WriteString("\n    ")
+		_, _ = bw.Write(data)
+	}
+	_, _ = bw.WriteString("\n  ]\n}\n")
+	return bw.Flush()
 }
 
-func toJSONNodes(nodes []ast.Node) []ast.JsonNode {
+// ToJSONNodes converts the diff tree into the serializable shape shared by the json and yaml formats.
+func ToJSONNodes(nodes []ast.Node) []ast.JsonNode {
 	res := make([]ast.JsonNode, 0, len(nodes))
 
 	for _, n := range nodes {
 		j := ast.JsonNode{
-			Key:  n.Key,
-			Type: actionToString(n.Action),
+			Key:      n.Key,
+			Type:     actionToString(n.Action),
+			Warnings: n.Warnings,
 		}
 
 		switch n.Action {
 		case ast.Nested:
-			j.Children = toJSONNodes(n.Children)
+			j.Children = ToJSONNodes(n.Children)
 
 		case ast.Added:
 			j.NewValue = n.NewVal
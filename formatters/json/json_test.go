@@ -43,7 +43,7 @@ func TestToJSONNodes_AllActions(t *testing.T) {
 		},
 	}
 
-	got := toJSONNodes(nodes)
+	got := ToJSONNodes(nodes)
 
 	want := []ast.JsonNode{
 		{
@@ -81,7 +81,7 @@ func TestToJSONNodes_AllActions(t *testing.T) {
 	}
 
 	if !reflect.DeepEqual(got, want) {
-		t.Fatalf("toJSONNodes mismatch\n got: %#v\nwant: %#v", got, want)
+		t.Fatalf("ToJSONNodes mismatch\n got: %#v\nwant: %#v", got, want)
 	}
 }
 
@@ -170,3 +170,25 @@ func TestRender_ReturnsValidJSON(t *testing.T) {
 		t.Fatalf("childB = %#v, want key=b type=removed oldValue=true", childB)
 	}
 }
+
+func TestWrite_MatchesMarshalIndent(t *testing.T) {
+	for _, nodes := range [][]ast.Node{
+		nil,
+		ast.BuildDiff(
+			map[string]any{"a": 1.0, "n": map[string]any{"b": "<x>"}},
+			map[string]any{"a": 2.0, "n": map[string]any{"b": "<x>", "c": []any{}}, "z": nil},
+		),
+	} {
+		want, err := stdjson.MarshalIndent(map[string]any{"diff": ToJSONNodes(nodes)}, "", "  ")
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := Render(nodes)
+		if err != nil {
+			t.Fatalf("Render returned error: %v", err)
+		}
+		if got != string(want) {
+			t.Errorf("got:\n%s\nwant:\n%s", got, want)
+		}
+	}
+}
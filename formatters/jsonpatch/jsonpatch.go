@@ -0,0 +1,108 @@
+package jsonpatch
+
+import (
+	"bufio"
+	"code/ast"
+	stdjson "encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	OpAdd     = "add"
+	OpRemove  = "remove"
+	OpReplace = "replace"
+)
+
+type Operation struct {
+	Op    string
+	Path  string
+	Value any
+}
+
+// MarshalJSON keeps "value" for add/replace even when it is null (RFC 6902 requires it)
+// and drops it for remove.
+func (o Operation) MarshalJSON() ([]byte, error) {
+	if o.Op == OpRemove {
+		return stdjson.Marshal(struct {
+			Op   string `json:"op"`
+			Path string `json:"path"`
+		}{o.Op, o.Path})
+	}
+	return stdjson.Marshal(struct {
+		Op    string `json:"op"`
+		Path  string `json:"path"`
+		Value any    `json:"value"`
+	}{o.Op, o.Path, o.Value})
+}
+
+// Write streams the patch operation by operation while walking the tree,
+// producing the same bytes as json.MarshalIndent of BuildPatch.
+func Write(w io.Writer, nodes []ast.Node) error {
+	bw := bufio.NewWriter(w)
+	count := 0
+	err := walkOps(nodes, "", func(op Operation) error {
+		data, err := stdjson.MarshalIndent(op, "  ", "  ")
+		if err != nil {
+			return fmt.Errorf("marshal json patch: %w", err)
+		}
+		if count == 0 {
+			_, _ = bw.WriteString("[")
+		} else {
+			_, _ = bw.WriteString(",")
+		}
+		_, _ = bw.WriteString("\n  ")
+		_, _ = bw.Write(data)
+		count++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if count == 0 {
+		_, _ = bw.WriteString("[]\n")
+	} else {
+		_, _ = bw.WriteString("\n]\n")
+	}
+	return bw.Flush()
+}
+
+func BuildPatch(nodes []ast.Node) []Operation {
+	ops := make([]Operation, 0, len(nodes))
+	_ = walkOps(nodes, "", func(op Operation) error {
+		ops = append(ops, op)
+		return nil
+	})
+	return ops
+}
+
+func walkOps(nodes []ast.Node, parent string, emit func(Operation) error) error {
+	for _, n := range nodes {
+		path := parent + "/" + escapeToken(n.Key)
+
+		var err error
+		switch n.Action {
+		case ast.Nested:
+			err = walkOps(n.Children, path, emit)
+		case ast.Added:
+			err = emit(Operation{Op: OpAdd, Path: path, Value: n.NewVal})
+		case ast.Removed:
+			err = emit(Operation{Op: OpRemove, Path: path})
+		case ast.Updated:
+			err = emit(Operation{Op: OpReplace, Path: path, Value: n.NewVal})
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// escapeToken encodes a key as an RFC 6901 reference token: "~" first, then "/".
+func escapeToken(key string) string {
+	key = strings.ReplaceAll(key, "~", "~0")
+	return strings.ReplaceAll(key, "/", "~1")
+}
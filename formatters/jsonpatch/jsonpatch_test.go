@@ -0,0 +1,145 @@
+package jsonpatch
+
+import (
+	"code/ast"
+	"code/parsers"
+	stdjson "encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEscapeToken(t *testing.T) {
+	cases := map[string]string{
+		"plain": "plain",
+		"a/b":   "a~1b",
+		"m~n":   "m~0n",
+		"~1":    "~01",
+		"/~":    "~1~0",
+	}
+	for in, want := range cases {
+		if got := escapeToken(in); got != want {
+			t.Fatalf("escapeToken(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestBuildPatch_AllActions(t *testing.T) {
+	nodes := []ast.Node{
+		{Key: "a/b", Action: ast.Added, NewVal: nil},
+		{Key: "keep", Action: ast.Unchanged, OldVal: 1},
+		{
+			Key:    "nested",
+			Action: ast.Nested,
+			Children: []ast.Node{
+				{Key: "m~n", Action: ast.Removed, OldVal: "x"},
+				{Key: "v", Action: ast.Updated, OldVal: 1, NewVal: false},
+			},
+		},
+	}
+
+	got := BuildPatch(nodes)
+	want := []Operation{
+		{Op: OpAdd, Path: "/a~1b", Value: nil},
+		{Op: OpRemove, Path: "/nested/m~0n"},
+		{Op: OpReplace, Path: "/nested/v", Value: false},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("BuildPatch mismatch\n got: %#v\nwant: %#v", got, want)
+	}
+}
+
+func TestWrite_ValueField(t *testing.T) {
+	var b strings.Builder
+	err := Write(&b, []ast.Node{
+		{Key: "a", Action: ast.Added, NewVal: nil},
+		{Key: "b", Action: ast.Removed, OldVal: 1},
+	})
+	if err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+	out := b.String()
+
+	var raw []map[string]any
+	if err := stdjson.Unmarshal([]byte(out), &raw); err != nil {
+		t.Fatalf("unmarshal output: %v\njson: %s", err, out)
+	}
+	if _, ok := raw[0]["value"]; !ok {
+		t.Fatalf("add op must carry value even when null: %s", out)
+	}
+	if _, ok := raw[1]["value"]; ok {
+		t.Fatalf("remove op must not carry value: %s", out)
+	}
+}
+
+func TestWrite_ApplyReproducesFile2(t *testing.T) {
+	for _, ext := range []string{"json", "yaml"} {
+		t.Run(ext, func(t *testing.T) {
+			parsed, err := parsers.ParseFiles(
+				"../../testdata/fixture/file1."+ext,
+				"../../testdata/fixture/file2."+ext,
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var b strings.Builder
+			if err := Write(&b, ast.BuildDiff(parsed[0], parsed[1])); err != nil {
+				t.Fatal(err)
+			}
+			out := b.String()
+
+			var ops []map[string]any
+			if err := stdjson.Unmarshal([]byte(out), &ops); err != nil {
+				t.Fatal(err)
+			}
+
+			doc := roundTrip(t, parsed[0])
+			for _, op := range ops {
+				apply(t, doc, op)
+			}
+
+			if want := roundTrip(t, parsed[1]); !reflect.DeepEqual(doc, want) {
+				t.Fatalf("patched document mismatch\n got: %#v\nwant: %#v", doc, want)
+			}
+		})
+	}
+}
+
+func roundTrip(t *testing.T, v map[string]any) map[string]any {
+	t.Helper()
+	data, err := stdjson.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out map[string]any
+	if err := stdjson.Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+// apply — минимальная реализация add/remove/replace для объектов.
+func apply(t *testing.T, doc map[string]any, op map[string]any) {
+	t.Helper()
+	tokens := strings.Split(op["path"].(string), "/")[1:]
+	parent := doc
+	for _, tok := range tokens[:len(tokens)-1] {
+		parent = parent[unescape(tok)].(map[string]any)
+	}
+	last := unescape(tokens[len(tokens)-1])
+
+	switch op["op"] {
+	case OpAdd, OpReplace:
+		parent[last] = op["value"]
+	case OpRemove:
+		delete(parent, last)
+	default:
+		t.Fatalf("unexpected op %v", op["op"])
+	}
+}
+
+func unescape(tok string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(tok, "~1", "/"), "~0", "~")
+}
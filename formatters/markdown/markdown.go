@@ -0,0 +1,156 @@
+package markdown
+
+import (
+	"bufio"
+	"bytes"
+	"code/ast"
+	"code/formatters/plain"
+	stdjson "encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+)
+
+const complexPlaceholder = "[complex value]"
+
+type change struct {
+	path   string
+	action ast.NodeType
+	old    any
+	new    any
+}
+
+// Write streams the table and then the <details> blocks, walking the change
+// list twice instead of buffering the blocks until the table is done.
+func Write(w io.Writer, nodes []ast.Node) error {
+	bw := bufio.NewWriter(w)
+	changes := collect(nil, nodes, "")
+	if len(changes) == 0 {
+		_, _ = bw.WriteString("No changes.\n")
+		return bw.Flush()
+	}
+
+	_, _ = bw.WriteString("| Path | Change | Old value | New value |\n")
+	_, _ = bw.WriteString("| --- | --- | --- | --- |\n")
+
+	for _, c := range changes {
+		oldCell, newCell := "", ""
+		if c.action != ast.Added {
+			oldCell = cell(c.old)
+		}
+		if c.action != ast.Removed {
+			newCell = cell(c.new)
+		}
+		fmt.Fprintf(bw, "| %s | %s | %s | %s |\n", codeSpan(c.path), c.action, oldCell, newCell)
+	}
+
+	for _, c := range changes {
+		if c.action != ast.Added && isComplex(c.old) {
+			if err := writeDetails(bw, c.path, "old value", c.old); err != nil {
+				return err
+			}
+		}
+		if c.action != ast.Removed && isComplex(c.new) {
+			if err := writeDetails(bw, c.path, "new value", c.new); err != nil {
+				return err
+			}
+		}
+	}
+
+	return bw.Flush()
+}
+
+func collect(out []change, nodes []ast.Node, parentPath string) []change {
+	for _, n := range nodes {
+		propPath := plain.JoinPath(parentPath, n.Key)
+
+		switch n.Action {
+		case ast.Nested:
+			out = collect(out, n.Children, propPath)
+		case ast.Added, ast.Removed, ast.Updated:
+			out = append(out, change{path: propPath, action: n.Action, old: n.OldVal, new: n.NewVal})
+		}
+	}
+	return out
+}
+
+func isComplex(v any) bool {
+	switch v.(type) {
+	case map[string]any, []any:
+		return true
+	default:
+		return false
+	}
+}
+
+func cell(v any) string {
+	if isComplex(v) {
+		return complexPlaceholder
+	}
+	data, err := marshal(v, "")
+	if err != nil {
+		return codeSpan(fmt.Sprintf("%v", v))
+	}
+	return codeSpan(string(data))
+}
+
+// marshal encodes v as JSON without escaping <, > and &, which a reader would
+// otherwise see as \u003c and friends: code spans and fences show text as is.
+func marshal(v any, indent string) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := stdjson.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", indent)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
+
+// codeSpan wraps s in code spans safe for a GFM table cell: each fence is longer
+// than any backtick run inside and pipes are escaped. A cell cannot hold a
+// newline, so every line gets its own span and the spans are joined by <br>,
+// which GFM would print literally inside a span.
+func codeSpan(s string) string {
+	lines := strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = codeSpanLine(line)
+		}
+	}
+	return strings.Join(lines, "<br>")
+}
+
+func codeSpanLine(s string) string {
+	fence := strings.Repeat("`", longestRun(s, '`')+1)
+	if strings.HasPrefix(s, "`") || strings.HasSuffix(s, "`") {
+		s = " " + s + " "
+	}
+	return fence + strings.ReplaceAll(s, "|", `\|`) + fence
+}
+
+func writeDetails(b io.Writer, path, label string, v any) error {
+	data, err := marshal(v, "  ")
+	if err != nil {
+		return fmt.Errorf("marshal %s of %q: %w", label, path, err)
+	}
+	fence := strings.Repeat("`", max(3, longestRun(string(data), '`')+1))
+
+	fmt.Fprintf(b, "\n<details>\n<summary><code>%s</code> (%s)</summary>\n\n", html.EscapeString(path), label)
+	fmt.Fprintf(b, "%sjson\n%s\n%s\n\n</details>\n", fence, data, fence)
+	return nil
+}
+
+func longestRun(s string, ch rune) int {
+	best, cur := 0, 0
+	for _, r := range s {
+		if r == ch {
+			cur++
+			best = max(best, cur)
+		} else {
+			cur = 0
+		}
+	}
+	return best
+}
@@ -0,0 +1,111 @@
+package markdown
+
+import (
+	"code/ast"
+	"strings"
+	"testing"
+)
+
+func TestWrite_Table(t *testing.T) {
+	nodes := []ast.Node{
+		{Key: "a", Action: ast.Added, NewVal: "x|y"},
+		{Key: "b", Action: ast.Unchanged, OldVal: 1},
+		{
+			Key:    "n",
+			Action: ast.Nested,
+			Children: []ast.Node{
+				{Key: "c", Action: ast.Updated, OldVal: map[string]any{"k": 1}, NewVal: nil},
+				{Key: "d", Action: ast.Removed, OldVal: 2},
+			},
+		},
+	}
+
+	var b strings.Builder
+	if err := Write(&b, nodes); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+	got := b.String()
+
+	want := "" +
+		"| Path | Change | Old value | New value |\n" +
+		"| --- | --- | --- | --- |\n" +
+		"| `a` | added |  | `\"x\\|y\"` |\n" +
+		"| `n.c` | updated | [complex value] | `null` |\n" +
+		"| `n.d` | removed | `2` |  |\n" +
+		"\n" +
+		"<details>\n" +
+		"<summary><code>n.c</code> (old value)</summary>\n" +
+		"\n" +
+		"```json\n" +
+		"{\n" +
+		"  \"k\": 1\n" +
+		"}\n" +
+		"```\n" +
+		"\n" +
+		"</details>\n"
+
+	if got != want {
+		t.Fatalf("Write() mismatch\n--- got ---\n%s\n--- want ---\n%s", got, want)
+	}
+}
+
+func TestWrite_NoChanges(t *testing.T) {
+	var b strings.Builder
+	if err := Write(&b, []ast.Node{{Key: "a", Action: ast.Unchanged, OldVal: 1}}); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+	got := b.String()
+	if got != "No changes.\n" {
+		t.Fatalf("Write() = %q, want %q", got, "No changes.\n")
+	}
+}
+
+func TestCodeSpan(t *testing.T) {
+	cases := map[string]string{
+		"plain":     "`plain`",
+		"a`b":       "``a`b``",
+		"`edge":     "`` `edge ``",
+		"x|y":       "`x\\|y`",
+		"two\nrows": "`two`<br>`rows`",
+		"a\n\nb":    "`a`<br><br>`b`",
+	}
+	for in, want := range cases {
+		if got := codeSpan(in); got != want {
+			t.Fatalf("codeSpan(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestWriteDetails_LongFence(t *testing.T) {
+	var b strings.Builder
+	if err := writeDetails(&b, "p<q", "new value", []any{"````"}); err != nil {
+		t.Fatal(err)
+	}
+	got := b.String()
+	if !strings.Contains(got, "<code>p&lt;q</code>") {
+		t.Fatalf("path is not html-escaped:\n%s", got)
+	}
+	if !strings.Contains(got, "`````json\n") {
+		t.Fatalf("fence is not longer than the backtick run:\n%s", got)
+	}
+}
+
+func TestWrite_HTMLCharacters(t *testing.T) {
+	nodes := []ast.Node{
+		{Key: "url", Action: ast.Updated, OldVal: "a<b>&c", NewVal: "https://x.test/?a=1&b=2"},
+		{Key: "obj", Action: ast.Added, NewVal: map[string]any{"q": "<&>"}},
+	}
+	var b strings.Builder
+	if err := Write(&b, nodes); err != nil {
+		t.Fatal(err)
+	}
+	got := b.String()
+	for _, want := range []string{"`\"a<b>&c\"`", "`\"https://x.test/?a=1&b=2\"`", `"q": "<&>"`} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("Write() lacks %s:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, `\u00`) {
+		t.Fatalf("Write() escapes HTML characters:\n%s", got)
+	}
+}
@@ -0,0 +1,77 @@
+package mergepatch
+
+import (
+	"code/ast"
+	"code/formatters/plain"
+	stdjson "encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var ErrNullValue = errors.New("merge patch cannot express a null value")
+
+// Write builds the whole patch before writing anything: a null found late in
+// the tree must fail the render without leaving half a document behind.
+func Write(w io.Writer, nodes []ast.Node) error {
+	patch, err := BuildPatch(nodes)
+	if err != nil {
+		return err
+	}
+
+	data, err := stdjson.MarshalIndent(patch, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal merge patch: %w", err)
+	}
+
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+func BuildPatch(nodes []ast.Node) (map[string]any, error) {
+	return build(nodes, "")
+}
+
+func build(nodes []ast.Node, parentPath string) (map[string]any, error) {
+	patch := map[string]any{}
+
+	for _, n := range nodes {
+		propPath := plain.JoinPath(parentPath, n.Key)
+
+		switch n.Action {
+		case ast.Nested:
+			child, err := build(n.Children, propPath)
+			if err != nil {
+				return nil, err
+			}
+			if len(child) > 0 {
+				patch[n.Key] = child
+			}
+		case ast.Removed:
+			patch[n.Key] = nil
+		case ast.Added, ast.Updated:
+			if containsNull(n.NewVal) {
+				return nil, fmt.Errorf("property %q: %w", propPath, ErrNullValue)
+			}
+			patch[n.Key] = n.NewVal
+		}
+	}
+
+	return patch, nil
+}
+
+// containsNull reports whether v is null or is an object holding null at any depth.
+// Arrays are replaced whole, so nulls inside them are representable.
+func containsNull(v any) bool {
+	switch x := v.(type) {
+	case nil:
+		return true
+	case map[string]any:
+		for _, vv := range x {
+			if containsNull(vv) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package mergepatch
+
+import (
+	"code/ast"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBuildPatch_AllActions(t *testing.T) {
+	nodes := []ast.Node{
+		{Key: "added", Action: ast.Added, NewVal: map[string]any{"x": 1}},
+		{Key: "list", Action: ast.Updated, OldVal: []any{1, 2}, NewVal: []any{nil, 3}},
+		{Key: "removed", Action: ast.Removed, OldVal: "x"},
+		{Key: "same", Action: ast.Unchanged, OldVal: true},
+		{
+			Key:    "nested",
+			Action: ast.Nested,
+			Children: []ast.Node{
+				{Key: "v", Action: ast.Updated, OldVal: 1, NewVal: 2},
+			},
+		},
+		{
+			Key:    "quiet",
+			Action: ast.Nested,
+			Children: []ast.Node{
+				{Key: "k", Action: ast.Unchanged, OldVal: 1},
+			},
+		},
+	}
+
+	got, err := BuildPatch(nodes)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string]any{
+		"added":   map[string]any{"x": 1},
+		"list":    []any{nil, 3},
+		"removed": nil,
+		"nested":  map[string]any{"v": 2},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("BuildPatch mismatch\n got: %#v\nwant: %#v", got, want)
+	}
+}
+
+func TestBuildPatch_NullValue(t *testing.T) {
+	cases := map[string][]ast.Node{
+		"updated to null": {
+			{Key: "a", Action: ast.Updated, OldVal: 1, NewVal: nil},
+		},
+		"added object with null": {
+			{
+				Key:    "common",
+				Action: ast.Nested,
+				Children: []ast.Node{
+					{Key: "b", Action: ast.Added, NewVal: map[string]any{"c": nil}},
+				},
+			},
+		},
+	}
+
+	for name, nodes := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := BuildPatch(nodes)
+			if !errors.Is(err, ErrNullValue) {
+				t.Fatalf("expected ErrNullValue, got %v", err)
+			}
+		})
+	}
+}
+
+func TestWrite_Empty(t *testing.T) {
+	var b strings.Builder
+	if err := Write(&b, []ast.Node{{Key: "a", Action: ast.Unchanged, OldVal: 1}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := b.String()
+	if got != "{}\n" {
+		t.Fatalf("Write() = %q, want %q", got, "{}\n")
+	}
+}
@@ -1,60 +1,79 @@
 package plain
 
 import (
+	"bufio"
 	"code/ast"
+	"code/formatters/color"
 	"fmt"
+	"io"
 	"strings"
 )
 
+type Options struct {
+	Color bool
+}
+
 func Render(nodes []ast.Node) (string, error) {
-	s, err := render(nodes, "")
-	if err != nil {
+	var b strings.Builder
+	if err := Write(&b, nodes, Options{}); err != nil {
 		return "", err
 	}
-	return strings.TrimRight(s, "\n"), nil
+	return strings.TrimRight(b.String(), "\n"), nil
+}
+
+// Write streams one line per changed property to w.
+func Write(w io.Writer, nodes []ast.Node, opts Options) error {
+	bw := bufio.NewWriter(w)
+	render(bw, nodes, "", opts)
+	return bw.Flush()
 }
 
-func render(nodes []ast.Node, parentPath string) (string, error) {
+func render(w *bufio.Writer, nodes []ast.Node, parentPath string, opts Options) {
 	base := "Property"
-	var b strings.Builder
 
 	for _, n := range nodes {
-		propPath := buildPath(parentPath, n.Key)
+		propPath := JoinPath(parentPath, n.Key)
+
+		for _, warning := range n.Warnings {
+			_, _ = w.WriteString(color.Paint(opts.Color, color.Yellow, fmt.Sprintf("Warning for '%s': %s", propPath, warning)))
+			_, _ = w.WriteString("\n")
+		}
 
 		switch n.Action {
 
 		case ast.Nested:
-			childStr, err := render(n.Children, propPath)
-			if err != nil {
-				return "", fmt.Errorf("render nested %q: %w", n.Key, err)
-			}
-			b.WriteString(childStr)
+			render(w, n.Children, propPath, opts)
 
 		case ast.Removed:
-			fmt.Fprintf(&b, "%s '%s' was removed\n", base, propPath)
+			writeLine(w, opts, n.Action, fmt.Sprintf("%s '%s' was removed", base, propPath))
 
 		case ast.Added:
-			newValStr := formatPlainValue(n.NewVal)
-			fmt.Fprintf(&b, "%s '%s' was added with value: %s\n", base, propPath, newValStr)
+			newValStr := FormatValue(n.NewVal)
+			writeLine(w, opts, n.Action, fmt.Sprintf("%s '%s' was added with value: %s", base, propPath, newValStr))
 
 		case ast.Updated:
-			oldValStr := formatPlainValue(n.OldVal)
-			newValStr := formatPlainValue(n.NewVal)
-			fmt.Fprintf(&b, "%s '%s' was updated. From %s to %s\n", base, propPath, oldValStr, newValStr)
+			oldValStr := FormatValue(n.OldVal)
+			newValStr := FormatValue(n.NewVal)
+			writeLine(w, opts, n.Action, fmt.Sprintf("%s '%s' was updated. From %s to %s", base, propPath, oldValStr, newValStr))
 		}
 	}
+}
 
-	return b.String(), nil
+func writeLine(w *bufio.Writer, opts Options, action ast.NodeType, s string) {
+	_, _ = w.WriteString(color.Paint(opts.Color, color.ForAction(action), s))
+	_, _ = w.WriteString("\n")
 }
 
-func buildPath(parent, key string) string {
+// JoinPath builds the dotted property path plain prints.
+func JoinPath(parent, key string) string {
 	if parent == "" {
 		return key
 	}
 	return parent + "." + key
 }
 
-func formatPlainValue(v interface{}) string {
+// FormatValue renders a value the way plain prints it: quoted strings, null and [complex value].
+func FormatValue(v interface{}) string {
 	switch vv := v.(type) {
 	case map[string]interface{}, []interface{}:
 		return "[complex value]"
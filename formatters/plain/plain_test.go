@@ -2,6 +2,7 @@ package plain
 
 import (
 	"code/ast"
+	"strings"
 	"testing"
 )
 
@@ -65,6 +66,46 @@ func TestRenderPlain(t *testing.T) {
 		"Property 'obj' was updated. From [complex value] to [complex value]"
 
 	if got != want {
-		t.Fatalf("Render() result mismatch.\n--- got ---\n%q\n--- want ---\n%q\n", got, want)
+		t.Fatalf("Write() result mismatch.\n--- got ---\n%q\n--- want ---\n%q\n", got, want)
+	}
+}
+
+func TestRenderPlain_Color(t *testing.T) {
+	nodes := []ast.Node{
+		{Key: "a", Action: ast.Removed, OldVal: 1},
+		{Key: "b", Action: ast.Unchanged, OldVal: 2},
+		{Key: "c", Action: ast.Added, NewVal: "x"},
+	}
+
+	var b strings.Builder
+	_ = Write(&b, nodes, Options{Color: true})
+	got := b.String()
+
+	want := "" +
+		"\x1b[31mProperty 'a' was removed\x1b[0m\n" +
+		"\x1b[32mProperty 'c' was added with value: 'x'\x1b[0m\n"
+
+	if got != want {
+		t.Fatalf("Write() mismatch.\n--- got ---\n%q\n--- want ---\n%q\n", got, want)
+	}
+}
+
+func TestRenderPlain_Warnings(t *testing.T) {
+	nodes := []ast.Node{
+		{Key: "a", Action: ast.Unchanged, OldVal: 1, Warnings: []string{"duplicate key \"a\""}},
+		{Key: "n", Action: ast.Nested, Children: []ast.Node{
+			{Key: "b", Action: ast.Added, NewVal: 2, Warnings: []string{"duplicate key \"n.b\""}},
+		}},
+	}
+
+	got, _ := Render(nodes)
+
+	want := "" +
+		"Warning for 'a': duplicate key \"a\"\n" +
+		"Warning for 'n.b': duplicate key \"n.b\"\n" +
+		"Property 'n.b' was added with value: 2"
+
+	if got != want {
+		t.Fatalf("Write() mismatch.\n--- got ---\n%q\n--- want ---\n%q\n", got, want)
 	}
 }
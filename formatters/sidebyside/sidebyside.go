@@ -0,0 +1,156 @@
+package sidebyside
+
+import (
+	"bufio"
+	"code/ast"
+	"code/formatters/stylish"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	DefaultWidth = 120
+	minColumn    = 10
+	indentSize   = 4
+	gutterSize   = 3
+	ellipsis     = "…"
+)
+
+// Gutter marks, as in sdiff(1).
+const (
+	markSame    = ' '
+	markRemoved = '<'
+	markAdded   = '>'
+	markUpdated = '|'
+)
+
+type Options struct {
+	Width int
+	Wrap  bool
+}
+
+type row struct {
+	left  string
+	right string
+	mark  byte
+}
+
+// Write prints each row as soon as the tree walk produces it.
+func Write(w io.Writer, nodes []ast.Node, opts Options) error {
+	width := opts.Width
+	if width <= 0 {
+		width = DefaultWidth
+	}
+	column := (width - gutterSize) / 2
+	if column < minColumn {
+		return fmt.Errorf("width %d is too small for two columns", width)
+	}
+
+	bw := bufio.NewWriter(w)
+	emit := func(r row) {
+		left := fit(r.left, column, opts.Wrap)
+		right := fit(r.right, column, opts.Wrap)
+		for i := 0; i < max(len(left), len(right)); i++ {
+			l, rr := part(left, i), part(right, i)
+			line := fmt.Sprintf("%s%s %c %s", l, strings.Repeat(" ", column-runeLen(l)), r.mark, rr)
+			_, _ = bw.WriteString(strings.TrimRight(line, " "))
+			_, _ = bw.WriteString("\n")
+		}
+	}
+
+	emit(row{left: "{", right: "{", mark: markSame})
+	walkRows(nodes, 1, emit)
+	emit(row{left: "}", right: "}", mark: markSame})
+
+	return bw.Flush()
+}
+
+func walkRows(nodes []ast.Node, depth int, emit func(row)) {
+	pad := strings.Repeat(" ", depth*indentSize)
+
+	for _, n := range nodes {
+		switch n.Action {
+		case ast.Nested:
+			open := pad + n.Key + ": {"
+			emit(row{left: open, right: open, mark: markSame})
+			walkRows(n.Children, depth+1, emit)
+			emit(row{left: pad + "}", right: pad + "}", mark: markSame})
+		case ast.Unchanged:
+			for _, l := range valueLines(pad, n.Key, n.OldVal, depth) {
+				emit(row{left: l, right: l, mark: markSame})
+			}
+		case ast.Removed:
+			for _, l := range valueLines(pad, n.Key, n.OldVal, depth) {
+				emit(row{left: l, mark: markRemoved})
+			}
+		case ast.Added:
+			for _, l := range valueLines(pad, n.Key, n.NewVal, depth) {
+				emit(row{right: l, mark: markAdded})
+			}
+		case ast.Updated:
+			oldLines := valueLines(pad, n.Key, n.OldVal, depth)
+			newLines := valueLines(pad, n.Key, n.NewVal, depth)
+			for i := 0; i < max(len(oldLines), len(newLines)); i++ {
+				emit(row{left: part(oldLines, i), right: part(newLines, i), mark: markUpdated})
+			}
+		}
+	}
+}
+
+func valueLines(pad, key string, v any, depth int) []string {
+	return strings.Split(pad+key+": "+stylish.Stringify(v, depth+1), "\n")
+}
+
+// fit cuts s into column-wide pieces when wrapping, otherwise truncates it with an ellipsis.
+// Wrapped pieces are indented to where the value starts, so nested keys stay readable.
+func fit(s string, column int, wrap bool) []string {
+	r := []rune(s)
+	if len(r) <= column {
+		return []string{s}
+	}
+	if !wrap {
+		return []string{string(r[:column-1]) + ellipsis}
+	}
+
+	out := []string{string(r[:column])}
+	r = r[column:]
+	hang := hangingIndent(s, column)
+	indent := strings.Repeat(" ", hang)
+	for len(r) > column-hang {
+		out = append(out, indent+string(r[:column-hang]))
+		r = r[column-hang:]
+	}
+	return append(out, indent+string(r))
+}
+
+// hangingIndent is the column the value of a "key: value" line starts at, or
+// the line's own indentation otherwise. It gives up on indents that would
+// leave less than half the column for text.
+func hangingIndent(s string, column int) int {
+	body := strings.TrimLeft(s, " ")
+	lead := len(s) - len(body)
+	hang := lead
+	if i := strings.Index(body, ": "); i >= 0 {
+		hang = lead + runeLen(body[:i]) + 2
+	}
+	switch {
+	case hang <= column/2:
+		return hang
+	case lead <= column/2:
+		return lead
+	default:
+		return 0
+	}
+}
+
+func part(lines []string, i int) string {
+	if i < len(lines) {
+		return lines[i]
+	}
+	return ""
+}
+
+func runeLen(s string) int {
+	return len([]rune(s))
+}
@@ -0,0 +1,96 @@
+package sidebyside
+
+import (
+	"code/ast"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWrite_Columns(t *testing.T) {
+	nodes := []ast.Node{
+		{Key: "a", Action: ast.Updated, OldVal: 1, NewVal: 2},
+		{
+			Key:    "n",
+			Action: ast.Nested,
+			Children: []ast.Node{
+				{Key: "b", Action: ast.Removed, OldVal: true},
+				{Key: "c", Action: ast.Added, NewVal: map[string]any{"d": nil}},
+			},
+		},
+		{Key: "s", Action: ast.Unchanged, OldVal: "x"},
+	}
+
+	var b strings.Builder
+	if err := Write(&b, nodes, Options{Width: 43}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := b.String()
+
+	want := strings.Join([]string{
+		"{                      {",
+		"    a: 1             |     a: 2",
+		"    n: {                   n: {",
+		"        b: true      <",
+		"                     >         c: {",
+		"                     >             d: null",
+		"                     >         }",
+		"    }                      }",
+		"    s: x                   s: x",
+		"}                      }",
+		"",
+	}, "\n")
+
+	if got != want {
+		t.Fatalf("Write() mismatch\n--- got ---\n%s\n--- want ---\n%s", got, want)
+	}
+}
+
+func TestWrite_TooNarrow(t *testing.T) {
+	if err := Write(io.Discard, nil, Options{Width: 20}); err == nil {
+		t.Fatalf("expected error for narrow width, got nil")
+	}
+}
+
+func TestFit(t *testing.T) {
+	if got := fit("short", 10, false); !reflect.DeepEqual(got, []string{"short"}) {
+		t.Fatalf("fit(short) = %q", got)
+	}
+	if got := fit("abcdefghijkl", 5, false); !reflect.DeepEqual(got, []string{"abcd…"}) {
+		t.Fatalf("fit(truncate) = %q", got)
+	}
+	if got := fit("abcdefghijkl", 5, true); !reflect.DeepEqual(got, []string{"abcde", "fghij", "kl"}) {
+		t.Fatalf("fit(wrap) = %q", got)
+	}
+	if got := fit("  k: abcdefghijkl", 10, true); !reflect.DeepEqual(got, []string{"  k: abcde", "     fghij", "     kl"}) {
+		t.Fatalf("fit(wrap value) = %q", got)
+	}
+	if got := fit("    long_key: abcdefgh", 10, true); !reflect.DeepEqual(got, []string{"    long_k", "    ey: ab", "    cdefgh"}) {
+		t.Fatalf("fit(wrap long key) = %q", got)
+	}
+}
+
+func TestWrite_Wrap(t *testing.T) {
+	nodes := []ast.Node{
+		{Key: "k", Action: ast.Added, NewVal: "0123456789abcdef"},
+	}
+
+	var b strings.Builder
+	if err := Write(&b, nodes, Options{Width: 33, Wrap: true}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := b.String()
+
+	want := strings.Join([]string{
+		"{                 {",
+		"                >     k: 01234567",
+		"                >        89abcdef",
+		"}                 }",
+		"",
+	}, "\n")
+
+	if got != want {
+		t.Fatalf("Write() mismatch\n--- got ---\n%s\n--- want ---\n%s", got, want)
+	}
+}
@@ -1,8 +1,11 @@
 package stylish
 
 import (
+	"bufio"
 	"code/ast"
+	"code/formatters/color"
 	"fmt"
+	"io"
 	"sort"
 	"strings"
 )
@@ -17,40 +20,63 @@ func indent(depth int) string {
 	return strings.Repeat(" ", n)
 }
 
+type Options struct {
+	Color bool
+}
+
 func Render(nodes []ast.Node) (string, error) {
-	return render(nodes, 1)
+	var b strings.Builder
+	if err := Write(&b, nodes, Options{}); err != nil {
+		return "", err
+	}
+	return strings.TrimRight(b.String(), "\n"), nil
+}
+
+// Write streams the diff to w line by line; nothing but the current value is
+// held in memory. bufio.Writer keeps the first write error and Flush reports it.
+func Write(w io.Writer, nodes []ast.Node, opts Options) error {
+	bw := bufio.NewWriter(w)
+	render(bw, nodes, 1, opts)
+	_, _ = bw.WriteString("\n")
+	return bw.Flush()
 }
 
-func render(nodes []ast.Node, depth int) (string, error) {
+func render(w *bufio.Writer, nodes []ast.Node, depth int, opts Options) {
 	base := indent(depth)
 	closeIndent := strings.Repeat(" ", (depth-1)*indentSize)
 
-	var b strings.Builder
-	b.WriteString("{\n")
+	_, _ = w.WriteString("{\n")
 	for _, n := range nodes {
+		for _, warning := range n.Warnings {
+			_, _ = w.WriteString(color.Paint(opts.Color, color.Yellow, fmt.Sprintf("%s# %s", base, warning)))
+			_, _ = w.WriteString("\n")
+		}
 		switch n.Action {
 		case ast.Nested:
-			childStr, err := render(n.Children, depth+1)
-			if err != nil {
-				return "", fmt.Errorf("render nested %q: %w", n.Key, err)
-			}
-			b.WriteString(fmt.Sprintf("%s  %s: %s\n", base, n.Key, childStr))
+			fmt.Fprintf(w, "%s  %s: ", base, n.Key)
+			render(w, n.Children, depth+1, opts)
+			_, _ = w.WriteString("\n")
 		case ast.Unchanged:
-			b.WriteString(fmt.Sprintf("%s  %s: %s\n", base, n.Key, stringify(n.OldVal, depth+1)))
+			line(w, opts, n.Action, fmt.Sprintf("%s  %s: %s", base, n.Key, Stringify(n.OldVal, depth+1)))
 		case ast.Removed:
-			b.WriteString(fmt.Sprintf("%s- %s: %s\n", base, n.Key, stringify(n.OldVal, depth+1)))
+			line(w, opts, n.Action, fmt.Sprintf("%s- %s: %s", base, n.Key, Stringify(n.OldVal, depth+1)))
 		case ast.Added:
-			b.WriteString(fmt.Sprintf("%s+ %s: %s\n", base, n.Key, stringify(n.NewVal, depth+1)))
+			line(w, opts, n.Action, fmt.Sprintf("%s+ %s: %s", base, n.Key, Stringify(n.NewVal, depth+1)))
 		case ast.Updated:
-			b.WriteString(fmt.Sprintf("%s- %s: %s\n", base, n.Key, stringify(n.OldVal, depth+1)))
-			b.WriteString(fmt.Sprintf("%s+ %s: %s\n", base, n.Key, stringify(n.NewVal, depth+1)))
+			line(w, opts, n.Action, fmt.Sprintf("%s- %s: %s", base, n.Key, Stringify(n.OldVal, depth+1)))
+			line(w, opts, n.Action, fmt.Sprintf("%s+ %s: %s", base, n.Key, Stringify(n.NewVal, depth+1)))
 		}
 	}
-	b.WriteString(closeIndent + "}")
-	return b.String(), nil
+	_, _ = w.WriteString(closeIndent + "}")
+}
+
+func line(w *bufio.Writer, opts Options, action ast.NodeType, s string) {
+	_, _ = w.WriteString(color.Paint(opts.Color, color.ForAction(action), s))
+	_, _ = w.WriteString("\n")
 }
 
-func stringify(v any, depth int) string {
+// Stringify renders a value the way stylish prints it at the given depth.
+func Stringify(v any, depth int) string {
 	if v == nil {
 		return "null"
 	}
@@ -68,7 +94,7 @@ func stringify(v any, depth int) string {
 		var b strings.Builder
 		b.WriteString("{\n")
 		for _, k := range keys {
-			b.WriteString(fmt.Sprintf("%s%s: %s\n", valueIndent, k, stringify(m[k], depth+1)))
+			b.WriteString(fmt.Sprintf("%s%s: %s\n", valueIndent, k, Stringify(m[k], depth+1)))
 		}
 		b.WriteString(closingIndent + "}")
 		return b.String()
@@ -78,7 +104,7 @@ func stringify(v any, depth int) string {
 		var b strings.Builder
 		b.WriteString("[\n")
 		for _, el := range arr {
-			b.WriteString(fmt.Sprintf("%s%s\n", valueIndent, stringify(el, depth+1)))
+			b.WriteString(fmt.Sprintf("%s%s\n", valueIndent, Stringify(el, depth+1)))
 		}
 		b.WriteString(closingIndent + "]")
 		return b.String()
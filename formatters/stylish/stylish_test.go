@@ -71,7 +71,7 @@ func TestStringify_Map(t *testing.T) {
 		"b": 2,
 		"a": 1,
 	}
-	got := stringify(m, 1)
+	got := Stringify(m, 1)
 
 	want := "{\n" +
 		"    a: 1\n" +
@@ -79,14 +79,14 @@ func TestStringify_Map(t *testing.T) {
 		"}"
 
 	if nl(got) != nl(want) {
-		t.Fatalf("stringify(map) mismatch\n--- got ---\n%s\n--- want ---\n%s", got, want)
+		t.Fatalf("Stringify(map) mismatch\n--- got ---\n%s\n--- want ---\n%s", got, want)
 	}
 }
 
 func TestStringify_Array(t *testing.T) {
 	arr := []any{1, "x"}
 
-	got := stringify(arr, 1)
+	got := Stringify(arr, 1)
 
 	want := "[\n" +
 		"    1\n" +
@@ -94,17 +94,17 @@ func TestStringify_Array(t *testing.T) {
 		"]"
 
 	if nl(got) != nl(want) {
-		t.Fatalf("stringify([]any) mismatch\n--- got ---\n%s\n--- want ---\n%s", got, want)
+		t.Fatalf("Stringify([]any) mismatch\n--- got ---\n%s\n--- want ---\n%s", got, want)
 	}
 }
 
 func TestStringify_NilAndPrimitive(t *testing.T) {
-	if got := stringify(nil, 1); got != "null" {
-		t.Fatalf("stringify(nil) = %q, want %q", got, "null")
+	if got := Stringify(nil, 1); got != "null" {
+		t.Fatalf("Stringify(nil) = %q, want %q", got, "null")
 	}
 
-	if got := stringify(42, 1); got != "42" {
-		t.Fatalf("stringify(42) = %q, want %q", got, "42")
+	if got := Stringify(42, 1); got != "42" {
+		t.Fatalf("Stringify(42) = %q, want %q", got, "42")
 	}
 }
 
@@ -117,3 +117,49 @@ func TestIndent_EdgeCases(t *testing.T) {
 		t.Fatalf("indent(1) = %q, want two spaces", got)
 	}
 }
+
+func TestWrite_Color(t *testing.T) {
+	nodes := []ast.Node{
+		{Key: "a", Action: ast.Updated, OldVal: 1, NewVal: 0},
+		{Key: "b", Action: ast.Unchanged, OldVal: 2},
+		{Key: "c", Action: ast.Added, NewVal: 3},
+		{Key: "d", Action: ast.Removed, OldVal: 4},
+	}
+	var b strings.Builder
+	_ = Write(&b, nodes, Options{Color: true})
+	got := b.String()
+	want := "{\n" +
+		"\x1b[33m  - a: 1\x1b[0m\n" +
+		"\x1b[33m  + a: 0\x1b[0m\n" +
+		"\x1b[2m    b: 2\x1b[0m\n" +
+		"\x1b[32m  + c: 3\x1b[0m\n" +
+		"\x1b[31m  - d: 4\x1b[0m\n" +
+		"}\n"
+
+	if got != want {
+		t.Fatalf("color mismatch\n--- got ---\n%q\n--- want ---\n%q", got, want)
+	}
+}
+
+func TestRender_Warnings(t *testing.T) {
+	nodes := []ast.Node{
+		{Key: "a", Action: ast.Unchanged, OldVal: 1, Warnings: []string{"duplicate key \"a\""}},
+		{Key: "n", Action: ast.Nested, Children: []ast.Node{
+			{Key: "b", Action: ast.Added, NewVal: 2, Warnings: []string{"first", "second"}},
+		}},
+	}
+	got, _ := Render(nodes)
+	want := "{\n" +
+		"  # duplicate key \"a\"\n" +
+		"    a: 1\n" +
+		"    n: {\n" +
+		"      # first\n" +
+		"      # second\n" +
+		"      + b: 2\n" +
+		"    }\n" +
+		"}"
+
+	if nl(got) != nl(want) {
+		t.Fatalf("mismatch\n--- got ---\n%s\n--- want ---\n%s", got, want)
+	}
+}
@@ -0,0 +1,71 @@
+package summary
+
+import (
+	"bufio"
+	"code/ast"
+	stdjson "encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const maxBarWidth = 50
+
+func Write(w io.Writer, nodes []ast.Node) error {
+	s := ast.Summarize(nodes)
+
+	keyWidth, countWidth, most := 0, 0, 0
+	for _, sec := range s.Sections {
+		keyWidth = max(keyWidth, len(sec.Key))
+		countWidth = max(countWidth, len(fmt.Sprint(sec.Changes())))
+		most = max(most, sec.Changes())
+	}
+
+	bw := bufio.NewWriter(w)
+	for _, sec := range s.Sections {
+		fmt.Fprintf(bw, " %-*s | %*d %s\n", keyWidth, sec.Key, countWidth, sec.Changes(), bar(sec, most))
+	}
+	fmt.Fprintf(bw, " %d %s changed, %d %s: %d added(+), %d removed(-), %d updated(~); max depth %d\n",
+		len(s.Sections), plural(len(s.Sections), "section", "sections"),
+		s.Added+s.Removed+s.Updated, plural(s.Added+s.Removed+s.Updated, "change", "changes"),
+		s.Added, s.Removed, s.Updated, s.MaxDepth)
+
+	return bw.Flush()
+}
+
+func WriteJSON(w io.Writer, nodes []ast.Node) error {
+	s := ast.Summarize(nodes)
+	if s.Sections == nil {
+		s.Sections = []ast.SectionSummary{}
+	}
+
+	data, err := stdjson.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal summary: %w", err)
+	}
+
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// bar draws +, - and ~ per change, scaled down like git diff --stat when the
+// largest section would not fit; every non-zero kind keeps at least one mark.
+func bar(sec ast.SectionSummary, most int) string {
+	scale := func(n int) int {
+		if most <= maxBarWidth || n == 0 {
+			return n
+		}
+		return max(1, n*maxBarWidth/most)
+	}
+
+	return strings.Repeat("+", scale(sec.Added)) +
+		strings.Repeat("-", scale(sec.Removed)) +
+		strings.Repeat("~", scale(sec.Updated))
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
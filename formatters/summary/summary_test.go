@@ -0,0 +1,66 @@
+package summary
+
+import (
+	"code/ast"
+	stdjson "encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestWrite_Stat(t *testing.T) {
+	nodes := []ast.Node{
+		{Key: "common", Action: ast.Nested, Children: []ast.Node{
+			{Key: "a", Action: ast.Added, NewVal: 1},
+			{Key: "b", Action: ast.Added, NewVal: 2},
+			{Key: "c", Action: ast.Removed, OldVal: 3},
+			{Key: "d", Action: ast.Nested, Children: []ast.Node{
+				{Key: "e", Action: ast.Updated, OldVal: 1, NewVal: 2},
+			}},
+		}},
+		{Key: "flag", Action: ast.Removed, OldVal: true},
+		{Key: "same", Action: ast.Unchanged, OldVal: 1},
+	}
+
+	var b strings.Builder
+	if err := Write(&b, nodes); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+	got := b.String()
+
+	want := "" +
+		" common | 4 ++-~\n" +
+		" flag   | 1 -\n" +
+		" 2 sections changed, 5 changes: 2 added(+), 2 removed(-), 1 updated(~); max depth 3\n"
+
+	if got != want {
+		t.Fatalf("Write() mismatch\n--- got ---\n%s\n--- want ---\n%s", got, want)
+	}
+}
+
+func TestBar_Scaled(t *testing.T) {
+	sec := ast.SectionSummary{Added: 100, Removed: 1, Updated: 99}
+	got := bar(sec, 200)
+
+	if len(got) > maxBarWidth+1 {
+		t.Fatalf("bar too wide: %d", len(got))
+	}
+	if strings.Count(got, "-") != 1 {
+		t.Fatalf("small counts must keep one mark: %q", got)
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	var b strings.Builder
+	if err := WriteJSON(&b, []ast.Node{{Key: "a", Action: ast.Unchanged, OldVal: 1}}); err != nil {
+		t.Fatalf("WriteJSON returned error: %v", err)
+	}
+	got := b.String()
+
+	var parsed ast.Summary
+	if err := stdjson.Unmarshal([]byte(got), &parsed); err != nil {
+		t.Fatalf("unmarshal output: %v\njson: %s", err, got)
+	}
+	if parsed.Unchanged != 1 || parsed.Sections == nil || len(parsed.Sections) != 0 {
+		t.Fatalf("unexpected summary: %s", got)
+	}
+}
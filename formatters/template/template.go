@@ -0,0 +1,115 @@
+package template
+
+import (
+	"code/ast"
+	"code/formatters/plain"
+	"code/formatters/stylish"
+	stdjson "encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	texttemplate "text/template"
+)
+
+// ErrNoTemplate is returned when Write is called without template text.
+var ErrNoTemplate = errors.New("no template given")
+
+// Options configures the template formatter.
+type Options struct {
+	Text     string
+	OldLabel string
+	NewLabel string
+}
+
+// Data is the template's dot.
+type Data struct {
+	Nodes    []ast.Node
+	OldLabel string
+	NewLabel string
+	Summary  ast.Summary
+}
+
+// Change is a changed leaf with its dotted path, as returned by the changes helper.
+type Change struct {
+	Path string
+	ast.Node
+}
+
+var funcs = texttemplate.FuncMap{
+	"join":        plain.JoinPath,
+	"value":       plain.FormatValue,
+	"stylish":     func(v any) string { return stylish.Stringify(v, 1) },
+	"json":        toJSON,
+	"changes":     changes,
+	"isAdded":     is(ast.Added),
+	"isRemoved":   is(ast.Removed),
+	"isUpdated":   is(ast.Updated),
+	"isUnchanged": is(ast.Unchanged),
+	"isNested":    is(ast.Nested),
+	"hasChanges":  ast.HasChanges,
+}
+
+// Write executes opts.Text against the diff with the helper functions above.
+// Output already written stays in w when execution fails part way.
+func Write(w io.Writer, nodes []ast.Node, opts Options) error {
+	if opts.Text == "" {
+		return ErrNoTemplate
+	}
+
+	tmpl, err := texttemplate.New("template").Funcs(funcs).Option("missingkey=error").Parse(opts.Text)
+	if err != nil {
+		return fmt.Errorf("parse template: %w", err)
+	}
+
+	err = tmpl.Execute(w, Data{
+		Nodes:    nodes,
+		OldLabel: opts.OldLabel,
+		NewLabel: opts.NewLabel,
+		Summary:  ast.Summarize(nodes),
+	})
+	if err != nil {
+		return fmt.Errorf("execute template: %w", err)
+	}
+	return nil
+}
+
+// is builds a predicate for the isAdded family that takes a node from .Nodes
+// or a Change from the changes helper alike.
+func is(action ast.NodeType) func(any) (bool, error) {
+	return func(v any) (bool, error) {
+		switch n := v.(type) {
+		case ast.Node:
+			return n.Action == action, nil
+		case Change:
+			return n.Action == action, nil
+		default:
+			return false, fmt.Errorf("want a node or a change, got %T", v)
+		}
+	}
+}
+
+// changes flattens the tree into added, removed and updated leaves.
+func changes(nodes []ast.Node) []Change {
+	return appendChanges(nil, nodes, "")
+}
+
+func appendChanges(out []Change, nodes []ast.Node, parentPath string) []Change {
+	for _, n := range nodes {
+		propPath := plain.JoinPath(parentPath, n.Key)
+		switch n.Action {
+		case ast.Nested:
+			out = appendChanges(out, n.Children, propPath)
+		case ast.Added, ast.Removed, ast.Updated:
+			out = append(out, Change{Path: propPath, Node: n})
+		}
+	}
+	return out
+}
+
+func toJSON(v any) (string, error) {
+	data, err := stdjson.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
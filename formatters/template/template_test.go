@@ -0,0 +1,76 @@
+package template
+
+import (
+	"code/ast"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestWrite_Changes(t *testing.T) {
+	nodes := []ast.Node{
+		{Key: "a", Action: ast.Added, NewVal: map[string]any{"k": 1}},
+		{Key: "b", Action: ast.Unchanged, OldVal: 1},
+		{
+			Key:    "n",
+			Action: ast.Nested,
+			Children: []ast.Node{
+				{Key: "c", Action: ast.Updated, OldVal: "x", NewVal: nil},
+				{Key: "d", Action: ast.Removed, OldVal: 2},
+			},
+		},
+	}
+	text := `{{.OldLabel}} -> {{.NewLabel}}: {{.Summary.Added}}+ {{.Summary.Removed}}- {{.Summary.Updated}}~
+{{range changes .Nodes}}{{if isAdded .}}+ {{.Path}} {{json .NewVal}}
+{{else if isRemoved .}}- {{.Path}}
+{{else}}~ {{.Path}} {{value .OldVal}} => {{value .NewVal}}
+{{end}}{{end}}`
+
+	var b strings.Builder
+	if err := Write(&b, nodes, Options{Text: text, OldLabel: "old", NewLabel: "new"}); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+	got := b.String()
+
+	want := "old -> new: 1+ 1- 1~\n" +
+		"+ a {\"k\":1}\n" +
+		"~ n.c 'x' => null\n" +
+		"- n.d\n"
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestWrite_Errors(t *testing.T) {
+	if err := Write(io.Discard, nil, Options{}); !errors.Is(err, ErrNoTemplate) {
+		t.Errorf("empty template: got %v, want ErrNoTemplate", err)
+	}
+	if err := Write(io.Discard, nil, Options{Text: "{{.Nope"}); err == nil {
+		t.Error("expected a parse error")
+	}
+	if err := Write(io.Discard, nil, Options{Text: "{{.Missing}}"}); err == nil {
+		t.Error("expected an execute error for an unknown field")
+	}
+}
+
+func TestWrite_Predicates(t *testing.T) {
+	nodes := []ast.Node{
+		{Key: "a", Action: ast.Updated, OldVal: 1, NewVal: 2},
+		{Key: "b", Action: ast.Unchanged, OldVal: 1},
+	}
+	text := `{{range .Nodes}}{{.Key}}:{{isUpdated .}} {{end}}{{range changes .Nodes}}{{.Path}}:{{isUpdated .}}{{end}}`
+
+	var b strings.Builder
+	if err := Write(&b, nodes, Options{Text: text}); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+	got := b.String()
+	if want := "a:true b:false a:true"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	if err := Write(io.Discard, nodes, Options{Text: "{{isAdded .Summary}}"}); err == nil {
+		t.Error("expected an error for a value that is neither a node nor a change")
+	}
+}
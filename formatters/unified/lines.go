@@ -0,0 +1,199 @@
+package unified
+
+import "context"
+
+type editOp int
+
+const (
+	opEqual editOp = iota
+	opDelete
+	opInsert
+)
+
+type edit struct {
+	op   editOp
+	aPos int
+	bPos int
+}
+
+// diffLines computes the shortest edit script turning a into b with the
+// linear-space variant of Myers' algorithm: each step finds where the forward
+// and backward searches meet and splits the problem there, so memory stays
+// O(N+M) however many lines differ. ctx is checked once per edit distance.
+func diffLines(ctx context.Context, a, b []string) ([]edit, error) {
+	size := 2*((len(a)+len(b)+1)/2) + 2
+	d := &differ{
+		ctx: ctx,
+		a:   a,
+		b:   b,
+		vf:  make([]int, size),
+		vb:  make([]int, size),
+	}
+	if err := d.compare(0, len(a), 0, len(b)); err != nil {
+		return nil, err
+	}
+	return groupChanges(d.edits), nil
+}
+
+type differ struct {
+	ctx   context.Context
+	a, b  []string
+	vf    []int
+	vb    []int
+	edits []edit
+}
+
+func (d *differ) compare(aLo, aHi, bLo, bHi int) error {
+	for aLo < aHi && bLo < bHi && d.a[aLo] == d.b[bLo] {
+		d.edits = append(d.edits, edit{op: opEqual, aPos: aLo, bPos: bLo})
+		aLo++
+		bLo++
+	}
+	suffix := 0
+	for aLo < aHi-suffix && bLo < bHi-suffix && d.a[aHi-suffix-1] == d.b[bHi-suffix-1] {
+		suffix++
+	}
+	aHi -= suffix
+	bHi -= suffix
+
+	switch {
+	case aLo == aHi:
+		for y := bLo; y < bHi; y++ {
+			d.edits = append(d.edits, edit{op: opInsert, aPos: aLo, bPos: y})
+		}
+	case bLo == bHi:
+		for x := aLo; x < aHi; x++ {
+			d.edits = append(d.edits, edit{op: opDelete, aPos: x, bPos: bLo})
+		}
+	default:
+		x, y, err := d.split(aLo, aHi, bLo, bHi)
+		if err != nil {
+			return err
+		}
+		if err := d.compare(aLo, x, bLo, y); err != nil {
+			return err
+		}
+		if err := d.compare(x, aHi, y, bHi); err != nil {
+			return err
+		}
+	}
+
+	for i := 0; i < suffix; i++ {
+		d.edits = append(d.edits, edit{op: opEqual, aPos: aHi + i, bPos: bHi + i})
+	}
+	return nil
+}
+
+// split runs the forward and backward searches over a[aLo:aHi] and b[bLo:bHi]
+// until they overlap and returns a point on a shortest edit path, so the two
+// halves can be diffed on their own. Both ranges are non-empty and share no
+// first or last line.
+func (d *differ) split(aLo, aHi, bLo, bHi int) (int, int, error) {
+	n, m := aHi-aLo, bHi-bLo
+	maxD := (n + m + 1) / 2
+	offset := maxD
+	vf := d.vf[:2*maxD+2]
+	vb := d.vb[:2*maxD+2]
+	for i := range vf {
+		vf[i] = -1
+		vb[i] = -1
+	}
+	vf[offset+1] = 0
+	vb[offset+1] = 0
+
+	delta := n - m
+	front := delta%2 != 0
+	// Diagonals that ran off the edge of the grid are skipped on later passes.
+	fStart, fEnd, bStart, bEnd := 0, 0, 0, 0
+
+	for step := 0; step < maxD; step++ {
+		if err := d.ctx.Err(); err != nil {
+			return 0, 0, err
+		}
+
+		for k := -step + fStart; k <= step-fEnd; k += 2 {
+			var x int
+			if k == -step || (k != step && vf[offset+k-1] < vf[offset+k+1]) {
+				x = vf[offset+k+1]
+			} else {
+				x = vf[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && d.a[aLo+x] == d.b[bLo+y] {
+				x++
+				y++
+			}
+			vf[offset+k] = x
+			switch {
+			case x > n:
+				fEnd += 2
+			case y > m:
+				fStart += 2
+			case front:
+				if bk := offset + delta - k; bk >= 0 && bk < len(vb) && vb[bk] != -1 && x >= n-vb[bk] {
+					return aLo + x, bLo + y, nil
+				}
+			}
+		}
+
+		for k := -step + bStart; k <= step-bEnd; k += 2 {
+			var x int
+			if k == -step || (k != step && vb[offset+k-1] < vb[offset+k+1]) {
+				x = vb[offset+k+1]
+			} else {
+				x = vb[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && d.a[aHi-x-1] == d.b[bHi-y-1] {
+				x++
+				y++
+			}
+			vb[offset+k] = x
+			switch {
+			case x > n:
+				bEnd += 2
+			case y > m:
+				bStart += 2
+			case !front:
+				if fk := offset + delta - k; fk >= 0 && fk < len(vf) && vf[fk] != -1 {
+					fx := vf[fk]
+					if fx >= n-x {
+						return aLo + fx, bLo + fx - (fk - offset), nil
+					}
+				}
+			}
+		}
+	}
+
+	// Unreachable for a shortest path; fall back to replacing the whole range.
+	return aHi, bLo, nil
+}
+
+// groupChanges lists the deletions of each run of changed lines before its
+// insertions, the way diff -u prints them.
+func groupChanges(edits []edit) []edit {
+	out := make([]edit, 0, len(edits))
+	for i := 0; i < len(edits); {
+		if edits[i].op == opEqual {
+			out = append(out, edits[i])
+			i++
+			continue
+		}
+		x, y := edits[i].aPos, edits[i].bPos
+		dels, ins := 0, 0
+		for ; i < len(edits) && edits[i].op != opEqual; i++ {
+			if edits[i].op == opDelete {
+				dels++
+			} else {
+				ins++
+			}
+		}
+		for j := 0; j < dels; j++ {
+			out = append(out, edit{op: opDelete, aPos: x + j, bPos: y})
+		}
+		for j := 0; j < ins; j++ {
+			out = append(out, edit{op: opInsert, aPos: x + dels, bPos: y + j})
+		}
+	}
+	return out
+}
@@ -0,0 +1,303 @@
+package unified
+
+import (
+	"bufio"
+	"code/ast"
+	"context"
+	stdjson "encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	SyntaxJSON = "json"
+	SyntaxYAML = "yaml"
+
+	DefaultContext = 3
+	indentUnit     = "  "
+)
+
+type Options struct {
+	Context  int
+	Syntax   string
+	OldLabel string
+	NewLabel string
+}
+
+// entry is an object member in canonical (sorted) order.
+type entry struct {
+	key string
+	val any
+}
+
+// Write prints hunks as they are found; both canonical documents and the edit
+// script are still held in memory while diffing.
+func Write(w io.Writer, nodes []ast.Node, opts Options) error {
+	return WriteContext(context.Background(), w, nodes, opts)
+}
+
+// WriteContext is Write that gives up with ctx.Err() once ctx is done, also
+// while the edit script is being computed and nothing has been written yet.
+func WriteContext(ctx context.Context, w io.Writer, nodes []ast.Node, opts Options) error {
+	if opts.Context < 0 {
+		return fmt.Errorf("negative context %d", opts.Context)
+	}
+	if opts.OldLabel == "" {
+		opts.OldLabel = "file1"
+	}
+	if opts.NewLabel == "" {
+		opts.NewLabel = "file2"
+	}
+
+	oldLines, newLines, err := Canonicalize(nodes, opts.Syntax)
+	if err != nil {
+		return err
+	}
+
+	edits, err := diffLines(ctx, oldLines, newLines)
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	unifiedDiff(bw, edits, oldLines, newLines, opts)
+	return bw.Flush()
+}
+
+// Canonicalize rebuilds both documents from the diff tree and prints them with
+// the key order of ast.BuildDiff and a fixed two-space indent.
+func Canonicalize(nodes []ast.Node, syntax string) ([]string, []string, error) {
+	oldLines, err := printLines(side(nodes, true), syntax)
+	if err != nil {
+		return nil, nil, err
+	}
+	newLines, err := printLines(side(nodes, false), syntax)
+	if err != nil {
+		return nil, nil, err
+	}
+	return oldLines, newLines, nil
+}
+
+// Document prints a single parsed document in the same canonical form.
+func Document(doc map[string]any, syntax string) ([]string, error) {
+	entries, _ := ordered(doc).([]entry)
+	return printLines(entries, syntax)
+}
+
+func printLines(doc []entry, syntax string) ([]string, error) {
+	switch syntax {
+	case "", SyntaxJSON:
+		return jsonLines(nil, doc, 0, "", ""), nil
+	case SyntaxYAML:
+		return yamlObject(nil, doc, 0), nil
+	default:
+		return nil, fmt.Errorf("unknown canonical syntax %q", syntax)
+	}
+}
+
+func side(nodes []ast.Node, old bool) []entry {
+	out := make([]entry, 0, len(nodes))
+	for _, n := range nodes {
+		switch n.Action {
+		case ast.Nested:
+			out = append(out, entry{key: n.Key, val: side(n.Children, old)})
+		case ast.Unchanged:
+			out = append(out, entry{key: n.Key, val: ordered(n.OldVal)})
+		case ast.Updated:
+			if old {
+				out = append(out, entry{key: n.Key, val: ordered(n.OldVal)})
+			} else {
+				out = append(out, entry{key: n.Key, val: ordered(n.NewVal)})
+			}
+		case ast.Removed:
+			if old {
+				out = append(out, entry{key: n.Key, val: ordered(n.OldVal)})
+			}
+		case ast.Added:
+			if !old {
+				out = append(out, entry{key: n.Key, val: ordered(n.NewVal)})
+			}
+		}
+	}
+	return out
+}
+
+func ordered(v any) any {
+	switch x := v.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(x))
+		for k := range x {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		out := make([]entry, 0, len(keys))
+		for _, k := range keys {
+			out = append(out, entry{key: k, val: ordered(x[k])})
+		}
+		return out
+	case []any:
+		out := make([]any, 0, len(x))
+		for _, el := range x {
+			out = append(out, ordered(el))
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+func jsonLines(out []string, v any, depth int, head, comma string) []string {
+	pad := strings.Repeat(indentUnit, depth)
+
+	switch x := v.(type) {
+	case []entry:
+		if len(x) == 0 {
+			return append(out, pad+head+"{}"+comma)
+		}
+		out = append(out, pad+head+"{")
+		for i, e := range x {
+			out = jsonLines(out, e.val, depth+1, jsonScalar(e.key)+": ", separator(i, len(x)))
+		}
+		return append(out, pad+"}"+comma)
+	case []any:
+		if len(x) == 0 {
+			return append(out, pad+head+"[]"+comma)
+		}
+		out = append(out, pad+head+"[")
+		for i, el := range x {
+			out = jsonLines(out, el, depth+1, "", separator(i, len(x)))
+		}
+		return append(out, pad+"]"+comma)
+	default:
+		return append(out, pad+head+jsonScalar(v)+comma)
+	}
+}
+
+func separator(i, n int) string {
+	if i < n-1 {
+		return ","
+	}
+	return ""
+}
+
+func jsonScalar(v any) string {
+	data, err := stdjson.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%q", fmt.Sprint(v))
+	}
+	return string(data)
+}
+
+func yamlObject(out []string, entries []entry, depth int) []string {
+	if len(entries) == 0 && depth == 0 {
+		return append(out, "{}")
+	}
+	pad := strings.Repeat(indentUnit, depth)
+	for _, e := range entries {
+		out = yamlValue(out, e.val, depth, pad+yamlScalar(e.key)+":")
+	}
+	return out
+}
+
+func yamlValue(out []string, v any, depth int, head string) []string {
+	switch x := v.(type) {
+	case []entry:
+		if len(x) == 0 {
+			return append(out, head+" {}")
+		}
+		return yamlObject(append(out, head), x, depth+1)
+	case []any:
+		if len(x) == 0 {
+			return append(out, head+" []")
+		}
+		out = append(out, head)
+		pad := strings.Repeat(indentUnit, depth+1)
+		for _, el := range x {
+			out = yamlValue(out, el, depth+1, pad+"-")
+		}
+		return out
+	default:
+		return append(out, head+" "+yamlScalar(v))
+	}
+}
+
+// yamlScalar lets yaml.v3 decide on quoting; multi-line strings fall back to a
+// JSON string, which is also a valid YAML double-quoted scalar.
+func yamlScalar(v any) string {
+	data, err := yaml.Marshal(v)
+	s := strings.TrimSuffix(string(data), "\n")
+	if err != nil || strings.Contains(s, "\n") {
+		return jsonScalar(v)
+	}
+	return s
+}
+
+func unifiedDiff(w *bufio.Writer, edits []edit, a, b []string, opts Options) {
+	for i, h := range hunks(edits, opts.Context) {
+		if i == 0 {
+			fmt.Fprintf(w, "--- %s\n+++ %s\n", opts.OldLabel, opts.NewLabel)
+		}
+		writeHunk(w, edits[h[0]:h[1]], a, b)
+	}
+}
+
+// hunks groups changed edits with their context; changes separated by at most
+// 2*context equal lines share a hunk, like diff -u.
+func hunks(edits []edit, context int) [][2]int {
+	var out [][2]int
+	for i, e := range edits {
+		if e.op == opEqual {
+			continue
+		}
+		lo := max(0, i-context)
+		hi := min(len(edits), i+context+1)
+		if last := len(out) - 1; last >= 0 && lo <= out[last][1] {
+			out[last][1] = max(out[last][1], hi)
+			continue
+		}
+		out = append(out, [2]int{lo, hi})
+	}
+	return out
+}
+
+func writeHunk(w *bufio.Writer, edits []edit, a, b []string) {
+	aCount, bCount := 0, 0
+	for _, e := range edits {
+		switch e.op {
+		case opEqual:
+			aCount++
+			bCount++
+		case opDelete:
+			aCount++
+		case opInsert:
+			bCount++
+		}
+	}
+
+	fmt.Fprintf(w, "@@ -%s +%s @@\n", hunkRange(edits[0].aPos, aCount), hunkRange(edits[0].bPos, bCount))
+	for _, e := range edits {
+		switch e.op {
+		case opEqual:
+			_, _ = w.WriteString(" " + a[e.aPos] + "\n")
+		case opDelete:
+			_, _ = w.WriteString("-" + a[e.aPos] + "\n")
+		case opInsert:
+			_, _ = w.WriteString("+" + b[e.bPos] + "\n")
+		}
+	}
+}
+
+func hunkRange(start, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprintf("%d", start+1)
+	default:
+		return fmt.Sprintf("%d,%d", start+1, count)
+	}
+}
@@ -0,0 +1,255 @@
+package unified
+
+import (
+	"code/ast"
+	"context"
+	"errors"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func sampleNodes() []ast.Node {
+	return []ast.Node{
+		{Key: "a", Action: ast.Unchanged, OldVal: 1},
+		{
+			Key:    "nested",
+			Action: ast.Nested,
+			Children: []ast.Node{
+				{Key: "list", Action: ast.Unchanged, OldVal: []any{"x", map[string]any{"k": true}}},
+				{Key: "v", Action: ast.Updated, OldVal: "old", NewVal: nil},
+			},
+		},
+		{Key: "z", Action: ast.Added, NewVal: map[string]any{"b": 2, "a": 1}},
+	}
+}
+
+func TestCanonicalize_JSON(t *testing.T) {
+	oldLines, newLines, err := Canonicalize(sampleNodes(), SyntaxJSON)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	wantOld := []string{
+		`{`,
+		`  "a": 1,`,
+		`  "nested": {`,
+		`    "list": [`,
+		`      "x",`,
+		`      {`,
+		`        "k": true`,
+		`      }`,
+		`    ],`,
+		`    "v": "old"`,
+		`  }`,
+		`}`,
+	}
+	wantNew := []string{
+		`{`,
+		`  "a": 1,`,
+		`  "nested": {`,
+		`    "list": [`,
+		`      "x",`,
+		`      {`,
+		`        "k": true`,
+		`      }`,
+		`    ],`,
+		`    "v": null`,
+		`  },`,
+		`  "z": {`,
+		`    "a": 1,`,
+		`    "b": 2`,
+		`  }`,
+		`}`,
+	}
+
+	if !reflect.DeepEqual(oldLines, wantOld) {
+		t.Fatalf("old mismatch\n got: %q\nwant: %q", oldLines, wantOld)
+	}
+	if !reflect.DeepEqual(newLines, wantNew) {
+		t.Fatalf("new mismatch\n got: %q\nwant: %q", newLines, wantNew)
+	}
+}
+
+func TestCanonicalize_YAML(t *testing.T) {
+	_, newLines, err := Canonicalize(sampleNodes(), SyntaxYAML)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{
+		"a: 1",
+		"nested:",
+		"  list:",
+		"    - x",
+		"    -",
+		"      k: true",
+		"  v: null",
+		"z:",
+		"  a: 1",
+		"  b: 2",
+	}
+
+	if !reflect.DeepEqual(newLines, want) {
+		t.Fatalf("yaml mismatch\n got: %q\nwant: %q", newLines, want)
+	}
+}
+
+func TestCanonicalize_UnknownSyntax(t *testing.T) {
+	if _, _, err := Canonicalize(nil, "toml"); err == nil {
+		t.Fatalf("expected error for unknown syntax, got nil")
+	}
+}
+
+func TestWrite_Hunks(t *testing.T) {
+	var b strings.Builder
+	if err := Write(&b, sampleNodes(), Options{Context: 1, Syntax: SyntaxYAML, OldLabel: "a.yaml", NewLabel: "b.yaml"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := b.String()
+
+	want := strings.Join([]string{
+		"--- a.yaml",
+		"+++ b.yaml",
+		"@@ -6,2 +6,5 @@",
+		"       k: true",
+		"-  v: old",
+		"+  v: null",
+		"+z:",
+		"+  a: 1",
+		"+  b: 2",
+		"",
+	}, "\n")
+
+	if got != want {
+		t.Fatalf("Write() mismatch\n--- got ---\n%s\n--- want ---\n%s", got, want)
+	}
+}
+
+func TestWrite_NoChanges(t *testing.T) {
+	var b strings.Builder
+	if err := Write(&b, []ast.Node{{Key: "a", Action: ast.Unchanged, OldVal: 1}}, Options{Context: DefaultContext}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := b.String()
+	if got != "" {
+		t.Fatalf("Write() = %q, want empty output", got)
+	}
+}
+
+func TestDiffLines_Reconstructs(t *testing.T) {
+	a := strings.Split("a b c d e f", " ")
+	b := strings.Split("x b c e f g", " ")
+
+	edits, err := diffLines(context.Background(), a, b)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if gotA, gotB := replay(edits, a, b); !reflect.DeepEqual(gotA, a) || !reflect.DeepEqual(gotB, b) {
+		t.Fatalf("edit script does not reconstruct inputs: %v / %v", gotA, gotB)
+	}
+}
+
+func TestDiffLines_Shortest(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 500; i++ {
+		a := randomLines(rng)
+		b := randomLines(rng)
+
+		edits, err := diffLines(context.Background(), a, b)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if gotA, gotB := replay(edits, a, b); !reflect.DeepEqual(gotA, a) || !reflect.DeepEqual(gotB, b) {
+			t.Fatalf("diffLines(%q, %q) does not reconstruct inputs: %q / %q", a, b, gotA, gotB)
+		}
+		changes := 0
+		for _, e := range edits {
+			if e.op != opEqual {
+				changes++
+			}
+		}
+		if want := len(a) + len(b) - 2*lcs(a, b); changes != want {
+			t.Fatalf("diffLines(%q, %q) made %d changes, want %d", a, b, changes, want)
+		}
+	}
+}
+
+func TestDiffLines_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := diffLines(ctx, []string{"a", "b"}, []string{"c", "d"}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+}
+
+func replay(edits []edit, a, b []string) ([]string, []string) {
+	gotA, gotB := []string{}, []string{}
+	for _, e := range edits {
+		switch e.op {
+		case opEqual:
+			gotA = append(gotA, a[e.aPos])
+			gotB = append(gotB, b[e.bPos])
+		case opDelete:
+			gotA = append(gotA, a[e.aPos])
+		case opInsert:
+			gotB = append(gotB, b[e.bPos])
+		}
+	}
+	return gotA, gotB
+}
+
+func randomLines(rng *rand.Rand) []string {
+	out := make([]string, rng.Intn(12))
+	for i := range out {
+		out[i] = string(rune('a' + rng.Intn(4)))
+	}
+	return out
+}
+
+func lcs(a, b []string) int {
+	dp := make([][]int, len(a)+1)
+	for i := range dp {
+		dp[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				dp[i][j] = dp[i+1][j+1] + 1
+			} else {
+				dp[i][j] = max(dp[i+1][j], dp[i][j+1])
+			}
+		}
+	}
+	return dp[0][0]
+}
+
+func TestHunkRange(t *testing.T) {
+	cases := []struct {
+		start, count int
+		want         string
+	}{
+		{0, 0, "0,0"},
+		{4, 1, "5"},
+		{4, 3, "5,3"},
+	}
+	for _, tc := range cases {
+		if got := hunkRange(tc.start, tc.count); got != tc.want {
+			t.Fatalf("hunkRange(%d, %d) = %q, want %q", tc.start, tc.count, got, tc.want)
+		}
+	}
+}
+
+func TestDocument(t *testing.T) {
+	got, err := Document(map[string]any{"b": []any{}, "a": map[string]any{}}, SyntaxJSON)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{`{`, `  "a": {},`, `  "b": []`, `}`}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Document mismatch\n got: %q\nwant: %q", got, want)
+	}
+}
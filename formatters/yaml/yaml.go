@@ -0,0 +1,97 @@
+package yaml
+
+import (
+	"code/ast"
+	"code/formatters/json"
+	"fmt"
+	"io"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+const indent = 2
+
+func Write(w io.Writer, nodes []ast.Node) error {
+	payload := struct {
+		Diff []ast.JsonNode `yaml:"diff"`
+	}{
+		Diff: json.ToJSONNodes(nodes),
+	}
+
+	return encode(w, payload)
+}
+
+// WriteCompact nests the diff by key; every changed leaf becomes a one-line
+// mapping such as {type: updated, old: true, new: null}.
+func WriteCompact(w io.Writer, nodes []ast.Node) error {
+	root, err := compactNode(nodes)
+	if err != nil {
+		return err
+	}
+
+	return encode(w, root)
+}
+
+func encode(w io.Writer, v any) error {
+	enc := yamlv3.NewEncoder(w)
+	enc.SetIndent(indent)
+	if err := enc.Encode(v); err != nil {
+		return fmt.Errorf("marshal yaml: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return fmt.Errorf("marshal yaml: %w", err)
+	}
+	return nil
+}
+
+func compactNode(nodes []ast.Node) (*yamlv3.Node, error) {
+	m := &yamlv3.Node{Kind: yamlv3.MappingNode}
+
+	for _, n := range nodes {
+		var (
+			val *yamlv3.Node
+			err error
+		)
+
+		switch n.Action {
+		case ast.Nested:
+			val, err = compactNode(n.Children)
+		case ast.Added:
+			val, err = leaf(n.Action, "new", n.NewVal)
+		case ast.Removed:
+			val, err = leaf(n.Action, "old", n.OldVal)
+		case ast.Unchanged:
+			val, err = leaf(n.Action, "value", n.OldVal)
+		case ast.Updated:
+			val, err = leaf(n.Action, "old", n.OldVal, "new", n.NewVal)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("encode %q: %w", n.Key, err)
+		}
+
+		m.Content = append(m.Content, scalar(n.Key), val)
+	}
+
+	return m, nil
+}
+
+func leaf(action ast.NodeType, kv ...any) (*yamlv3.Node, error) {
+	m := &yamlv3.Node{Kind: yamlv3.MappingNode, Style: yamlv3.FlowStyle}
+	m.Content = append(m.Content, scalar("type"), scalar(string(action)))
+
+	for i := 0; i < len(kv); i += 2 {
+		var val yamlv3.Node
+		if err := val.Encode(kv[i+1]); err != nil {
+			return nil, err
+		}
+		m.Content = append(m.Content, scalar(kv[i].(string)), &val)
+	}
+
+	return m, nil
+}
+
+func scalar(s string) *yamlv3.Node {
+	return &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: s}
+}
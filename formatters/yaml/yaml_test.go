@@ -0,0 +1,86 @@
+package yaml
+
+import (
+	"code/ast"
+	"reflect"
+	"strings"
+	"testing"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+func sampleNodes() []ast.Node {
+	return []ast.Node{
+		{Key: "a", Action: ast.Added, NewVal: 1},
+		{
+			Key:    "common",
+			Action: ast.Nested,
+			Children: []ast.Node{
+				{Key: "setting3", Action: ast.Updated, OldVal: true, NewVal: nil},
+				{Key: "true", Action: ast.Removed, OldVal: "x"},
+			},
+		},
+		{Key: "z", Action: ast.Unchanged, OldVal: []any{1, 2}},
+	}
+}
+
+func TestWrite_SameShapeAsJSON(t *testing.T) {
+	var b strings.Builder
+	if err := Write(&b, sampleNodes()); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+	got := b.String()
+
+	want := "" +
+		"diff:\n" +
+		"  - key: a\n" +
+		"    type: added\n" +
+		"    newValue: 1\n" +
+		"  - key: common\n" +
+		"    type: nested\n" +
+		"    children:\n" +
+		"      - key: setting3\n" +
+		"        type: updated\n" +
+		"        oldValue: true\n" +
+		"      - key: \"true\"\n" +
+		"        type: removed\n" +
+		"        oldValue: x\n" +
+		"  - key: z\n" +
+		"    type: unchanged\n" +
+		"    oldValue:\n" +
+		"      - 1\n" +
+		"      - 2\n"
+
+	if got != want {
+		t.Fatalf("Write() mismatch\n--- got ---\n%s\n--- want ---\n%s", got, want)
+	}
+}
+
+func TestWriteCompact(t *testing.T) {
+	var b strings.Builder
+	if err := WriteCompact(&b, sampleNodes()); err != nil {
+		t.Fatalf("WriteCompact returned error: %v", err)
+	}
+	got := b.String()
+
+	want := "" +
+		"a: {type: added, new: 1}\n" +
+		"common:\n" +
+		"  setting3: {type: updated, old: true, new: null}\n" +
+		"  \"true\": {type: removed, old: x}\n" +
+		"z: {type: unchanged, value: [1, 2]}\n"
+
+	if got != want {
+		t.Fatalf("WriteCompact() mismatch\n--- got ---\n%s\n--- want ---\n%s", got, want)
+	}
+
+	var parsed map[string]any
+	if err := yamlv3.Unmarshal([]byte(got), &parsed); err != nil {
+		t.Fatalf("compact output is not valid yaml: %v", err)
+	}
+	leaf := parsed["common"].(map[string]any)["setting3"]
+	wantLeaf := map[string]any{"type": "updated", "old": true, "new": nil}
+	if !reflect.DeepEqual(leaf, wantLeaf) {
+		t.Fatalf("leaf = %#v, want %#v", leaf, wantLeaf)
+	}
+}
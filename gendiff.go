@@ -3,16 +3,25 @@ package code
 import (
 	"code/ast"
 	"code/formatters"
-	"code/parsers"
-	"fmt"
+	"context"
 )
 
 func GenDiff(path1, path2, format string) (string, error) {
-	parsed, err := parsers.ParseFiles(path1, path2)
+	return GenDiffWithOptions(path1, path2, format, formatters.DefaultOptions())
+}
+
+func GenDiffWithOptions(path1, path2, format string, opts formatters.Options) (string, error) {
+	res, err := Diff(context.Background(), FileSource(path1), FileSource(path2))
 	if err != nil {
-		return "", fmt.Errorf("parse files: %w", err)
+		return "", err
 	}
-	nodes := ast.BuildDiff(parsed[0], parsed[1])
+	return res.Render(format, opts)
+}
 
-	return formatters.Render(format, nodes)
+func DiffFiles(path1, path2 string) ([]ast.Node, error) {
+	res, err := Diff(context.Background(), FileSource(path1), FileSource(path2))
+	if err != nil {
+		return nil, err
+	}
+	return res.Nodes, nil
 }
@@ -1,6 +1,7 @@
 package code
 
 import (
+	"code/ast"
 	"os"
 	"path/filepath"
 	"testing"
@@ -27,3 +28,23 @@ func TestGenDiff_FromTempFiles(t *testing.T) {
 		t.Fatalf("GenDiff:\n got:\n%q\nwant:\n%q", got, want)
 	}
 }
+
+func TestDiffFiles_HasChanges(t *testing.T) {
+	t.Parallel()
+
+	same, err := DiffFiles("testdata/fixture/file1.json", "testdata/fixture/file1.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ast.HasChanges(same) {
+		t.Fatalf("equal documents reported as changed")
+	}
+
+	changed, err := DiffFiles("testdata/fixture/file1.json", "testdata/fixture/file2.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ast.HasChanges(changed) {
+		t.Fatalf("different documents reported as equal")
+	}
+}
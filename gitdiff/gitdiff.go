@@ -0,0 +1,218 @@
+package gitdiff
+
+import (
+	"bytes"
+	"code/ast"
+	"code/parsers"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// NullFile is what git passes for the missing side of an added or deleted file.
+const NullFile = "/dev/null"
+
+const DriverName = "gendiff"
+
+// ReadBlob returns the contents of path at rev without touching the work
+// tree. A relative path is resolved against repoDir, as git does for "./".
+// A blob larger than maxBytes fails with *parsers.SizeLimitError before it is
+// read; zero means no limit.
+func ReadBlob(ctx context.Context, repoDir, rev, path string, maxBytes int64) ([]byte, error) {
+	spec := rev + ":" + path
+	if !filepath.IsAbs(path) && !strings.HasPrefix(path, "./") {
+		spec = rev + ":./" + filepath.ToSlash(path)
+	}
+	if maxBytes > 0 {
+		out, err := run(ctx, repoDir, "cat-file", "-s", spec)
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", spec, err)
+		}
+		size, err := strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("read %s: size %q: %w", spec, out, err)
+		}
+		if size > maxBytes {
+			return nil, fmt.Errorf("read %s: %w", spec, &parsers.SizeLimitError{Size: size, Limit: maxBytes})
+		}
+	}
+	out, err := run(ctx, repoDir, "cat-file", "blob", spec)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", spec, err)
+	}
+	return out, nil
+}
+
+// DiffRevisions diffs path between two revisions of the repository at repoDir.
+// opts bounds and checks both blobs as it does any other input.
+func DiffRevisions(ctx context.Context, repoDir, rev1, rev2, path string, opts parsers.Options) ([]ast.Node, error) {
+	var warnings []ast.Warning
+	docs := make([]map[string]any, 0, 2)
+	for _, rev := range []string{rev1, rev2} {
+		data, err := ReadBlob(ctx, repoDir, rev, path, opts.MaxBytes)
+		if err != nil {
+			return nil, err
+		}
+		doc, err := parse(ctx, data, rev+":"+path, opts, &warnings)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
+	return buildDiff(ctx, docs, warnings)
+}
+
+// DiffDriverFiles diffs the two temporary files git hands to an external diff
+// driver. The format comes from path because the temp names are arbitrary.
+func DiffDriverFiles(ctx context.Context, path, oldFile, newFile string, opts parsers.Options) ([]ast.Node, error) {
+	var warnings []ast.Warning
+	docs := make([]map[string]any, 0, 2)
+	for _, f := range []string{oldFile, newFile} {
+		if f == NullFile {
+			docs = append(docs, map[string]any{})
+			continue
+		}
+		data, err := readFile(f, opts.MaxBytes)
+		if err != nil {
+			return nil, err
+		}
+		doc, err := parse(ctx, data, path, opts, &warnings)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
+	return buildDiff(ctx, docs, warnings)
+}
+
+// parse is parsers.ParseContext that also turns the repeated keys opts warns
+// about into warnings for the diff tree.
+func parse(ctx context.Context, data []byte, name string, opts parsers.Options, warnings *[]ast.Warning) (map[string]any, error) {
+	onDuplicate := opts.OnDuplicate
+	opts.OnDuplicate = func(d parsers.DuplicateKey) {
+		*warnings = append(*warnings, ast.Warning{Path: d.Path, Text: d.Warning(name)})
+		if onDuplicate != nil {
+			onDuplicate(d)
+		}
+	}
+	return parsers.ParseContext(ctx, data, "", name, opts)
+}
+
+// buildDiff diffs both documents and annotates the tree with warnings, as
+// code.Diff does.
+func buildDiff(ctx context.Context, docs []map[string]any, warnings []ast.Warning) ([]ast.Node, error) {
+	nodes, err := ast.BuildDiffContext(ctx, docs[0], docs[1])
+	if err != nil {
+		return nil, err
+	}
+	ast.Annotate(nodes, warnings)
+	return nodes, nil
+}
+
+func readFile(name string, maxBytes int64) ([]byte, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("read %q: %w", name, err)
+	}
+	defer f.Close()
+
+	data, err := parsers.ReadLimited(f, maxBytes)
+	if err != nil {
+		return nil, fmt.Errorf("read %q: %w", name, err)
+	}
+	return data, nil
+}
+
+type SetupOptions struct {
+	// Command is the shell command git should run; "gendiff" if empty.
+	Command string
+	// Textconv registers "<command> git textconv" instead of an external diff driver.
+	Textconv bool
+	// Patterns are the gitattributes patterns routed to the driver.
+	Patterns []string
+}
+
+// Setup registers gendiff in the repository's local config and
+// .git/info/attributes, leaving tracked files untouched.
+func Setup(ctx context.Context, repoDir string, opts SetupOptions) error {
+	command := opts.Command
+	if command == "" {
+		command = "gendiff"
+	}
+	patterns := opts.Patterns
+	if len(patterns) == 0 {
+		patterns = []string{"*.json", "*.yaml", "*.yml"}
+	}
+
+	key, value := "diff."+DriverName+".command", command+" git driver"
+	if opts.Textconv {
+		key, value = "diff."+DriverName+".textconv", command+" git textconv"
+	}
+	if _, err := run(ctx, repoDir, "config", "--local", key, value); err != nil {
+		return err
+	}
+
+	gitDir, err := run(ctx, repoDir, "rev-parse", "--absolute-git-dir")
+	if err != nil {
+		return err
+	}
+	attrPath := filepath.Join(strings.TrimSpace(string(gitDir)), "info", "attributes")
+
+	existing, err := os.ReadFile(attrPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("read %q: %w", attrPath, err)
+	}
+	lines := strings.Split(string(existing), "\n")
+
+	var add strings.Builder
+	for _, p := range patterns {
+		line := p + " diff=" + DriverName
+		if !contains(lines, line) {
+			add.WriteString(line + "\n")
+		}
+	}
+	if add.Len() == 0 {
+		return nil
+	}
+	if len(existing) > 0 && !bytes.HasSuffix(existing, []byte("\n")) {
+		existing = append(existing, '\n')
+	}
+
+	if err := os.MkdirAll(filepath.Dir(attrPath), 0o755); err != nil {
+		return fmt.Errorf("create %q: %w", filepath.Dir(attrPath), err)
+	}
+	if err := os.WriteFile(attrPath, append(existing, add.String()...), 0o644); err != nil {
+		return fmt.Errorf("write %q: %w", attrPath, err)
+	}
+	return nil
+}
+
+func contains(lines []string, want string) bool {
+	for _, l := range lines {
+		if strings.TrimSpace(l) == want {
+			return true
+		}
+	}
+	return false
+}
+
+func run(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("git %s: %s: %w", args[0], msg, err)
+		}
+		return nil, fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return out, nil
+}
@@ -0,0 +1,177 @@
+package gitdiff
+
+import (
+	"code/ast"
+	"code/parsers"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir := t.TempDir()
+	gitRun(t, dir, "init", "-q")
+	gitRun(t, dir, "config", "user.email", "test@example.com")
+	gitRun(t, dir, "config", "user.name", "test")
+	return dir
+}
+
+func gitRun(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+}
+
+func commitFile(t *testing.T, dir, rel, body, msg string) {
+	t.Helper()
+	p := filepath.Join(dir, rel)
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	gitRun(t, dir, "add", rel)
+	gitRun(t, dir, "commit", "-q", "-m", msg)
+}
+
+func TestDiffRevisions(t *testing.T) {
+	dir := newRepo(t)
+	commitFile(t, dir, "conf/values.yaml", "a: 1\nb: x\n", "one")
+	gitRun(t, dir, "tag", "v1")
+	commitFile(t, dir, "conf/values.yaml", "a: 2\nb: x\n", "two")
+
+	// the work tree no longer matters once the blobs are committed
+	if err := os.Remove(filepath.Join(dir, "conf", "values.yaml")); err != nil {
+		t.Fatal(err)
+	}
+
+	nodes, err := DiffRevisions(context.Background(), dir, "v1", "HEAD", "conf/values.yaml", parsers.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 2 || nodes[0].Action != ast.Updated || nodes[1].Action != ast.Unchanged {
+		t.Fatalf("unexpected diff: %#v", nodes)
+	}
+
+	if _, err := DiffRevisions(context.Background(), dir, "v1", "HEAD", "missing.yaml", parsers.Options{}); err == nil {
+		t.Fatalf("expected error for a path missing from the revision")
+	}
+}
+
+func TestDiffRevisions_Limits(t *testing.T) {
+	dir := newRepo(t)
+	bomb := "a: &a [x, x, x, x, x, x, x, x, x]\n"
+	for c, prev := 'b', 'a'; c <= 'h'; c, prev = c+1, c {
+		bomb += fmt.Sprintf("%c: &%c [*%c, *%c, *%c, *%c, *%c, *%c, *%c, *%c, *%c]\n", c, c, prev, prev, prev, prev, prev, prev, prev, prev, prev)
+	}
+	commitFile(t, dir, "values.yaml", "a: 1\n", "one")
+	gitRun(t, dir, "tag", "v1")
+	commitFile(t, dir, "values.yaml", bomb, "bomb")
+
+	_, err := DiffRevisions(context.Background(), dir, "v1", "HEAD", "values.yaml", parsers.Options{Limits: parsers.DefaultLimits()})
+	if !errors.Is(err, parsers.ErrLimitExceeded) {
+		t.Fatalf("got %v, want a limit error", err)
+	}
+}
+
+func TestReadBlob_MaxBytes(t *testing.T) {
+	dir := newRepo(t)
+	commitFile(t, dir, "values.json", `{"a": "0123456789"}`, "one")
+
+	_, err := ReadBlob(context.Background(), dir, "HEAD", "values.json", 8)
+	var sizeErr *parsers.SizeLimitError
+	if !errors.As(err, &sizeErr) || sizeErr.Size != 19 || sizeErr.Limit != 8 {
+		t.Fatalf("got %v, want a size limit error with the blob's size", err)
+	}
+
+	data, err := ReadBlob(context.Background(), dir, "HEAD", "values.json", 19)
+	if err != nil || len(data) != 19 {
+		t.Fatalf("got %q, %v; want the whole blob", data, err)
+	}
+}
+
+func TestDiffRevisions_DuplicateWarnings(t *testing.T) {
+	dir := newRepo(t)
+	commitFile(t, dir, "v.yaml", "a: 1\n", "one")
+	commitFile(t, dir, "v.yaml", "a: 1\na: 2\n", "two")
+
+	nodes, err := DiffRevisions(context.Background(), dir, "HEAD~1", "HEAD", "v.yaml", parsers.Options{Duplicates: parsers.WarnDuplicates})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 1 || nodes[0].Action != ast.Updated || len(nodes[0].Warnings) != 1 ||
+		!strings.HasPrefix(nodes[0].Warnings[0], `duplicate key "a" at HEAD:v.yaml:2:1`) {
+		t.Fatalf("unexpected diff: %#v", nodes)
+	}
+}
+
+func TestDiffDriverFiles_Limits(t *testing.T) {
+	tmp := filepath.Join(t.TempDir(), "XXXXXX")
+	if err := os.WriteFile(tmp, []byte(`{"a": [[[1]]]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, limits := range []parsers.Limits{{MaxBytes: 4}, {MaxDepth: 2}} {
+		_, err := DiffDriverFiles(context.Background(), "conf/app.json", NullFile, tmp, parsers.Options{Limits: limits})
+		if !errors.Is(err, parsers.ErrLimitExceeded) {
+			t.Fatalf("%+v: got %v, want a limit error", limits, err)
+		}
+	}
+}
+
+func TestDiffDriverFiles_NullSide(t *testing.T) {
+	dir := t.TempDir()
+	tmp := filepath.Join(dir, "XXXXXX")
+	if err := os.WriteFile(tmp, []byte(`{"a": 1}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	nodes, err := DiffDriverFiles(context.Background(), "conf/app.json", NullFile, tmp, parsers.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 1 || nodes[0].Action != ast.Added {
+		t.Fatalf("unexpected diff: %#v", nodes)
+	}
+}
+
+func TestSetup_Idempotent(t *testing.T) {
+	dir := newRepo(t)
+	ctx := context.Background()
+
+	for range 2 {
+		if err := Setup(ctx, dir, SetupOptions{Patterns: []string{"*.yaml"}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	attrs, err := os.ReadFile(filepath.Join(dir, ".git", "info", "attributes"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(string(attrs), "*.yaml diff=gendiff") != 1 {
+		t.Fatalf("unexpected attributes:\n%s", attrs)
+	}
+
+	out, err := run(ctx, dir, "config", "diff.gendiff.command")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(string(out)); got != "gendiff git driver" {
+		t.Fatalf("diff.gendiff.command = %q", got)
+	}
+}
@@ -0,0 +1,40 @@
+package code
+
+import (
+	"code/ast"
+	"code/stream"
+	"context"
+	"fmt"
+	"os"
+)
+
+// DiffLargeFiles diffs two JSON files token by token with package stream
+// instead of parsing them into memory; see there for memory bounds. The result
+// holds only changes, so Summary.Unchanged is always 0.
+func DiffLargeFiles(ctx context.Context, path1, path2 string, opts stream.Options) (*Result, error) {
+	f1, err := os.Open(path1)
+	if err != nil {
+		return nil, fmt.Errorf("stream diff: %w", err)
+	}
+	defer f1.Close()
+
+	f2, err := os.Open(path2)
+	if err != nil {
+		return nil, fmt.Errorf("stream diff: %w", err)
+	}
+	defer f2.Close()
+
+	opts.LeftName, opts.RightName = path1, path2
+	nodes, err := stream.Diff(ctx, f1, f2, opts)
+	if err != nil {
+		return nil, fmt.Errorf("stream diff: %w", err)
+	}
+
+	return &Result{
+		Nodes:      nodes,
+		LeftLabel:  path1,
+		RightLabel: path2,
+		Changed:    ast.HasChanges(nodes),
+		Summary:    ast.Summarize(nodes),
+	}, nil
+}
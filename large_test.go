@@ -0,0 +1,44 @@
+package code
+
+import (
+	"code/formatters"
+	"code/stream"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDiffLargeFiles(t *testing.T) {
+	dir := t.TempDir()
+	left := filepath.Join(dir, "left.json")
+	right := filepath.Join(dir, "right.json")
+	if err := os.WriteFile(left, []byte(`{"a":1,"n":{"b":[1,2],"c":"x"},"gone":true}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(right, []byte(`{"n":{"c":"x","b":[1,3]},"a":1,"new":null}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := DiffLargeFiles(context.Background(), left, right, stream.Options{})
+	if err != nil {
+		t.Fatalf("DiffLargeFiles returned error: %v", err)
+	}
+	got, err := res.Render("plain", formatters.DefaultOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Property 'gone' was removed\n" +
+		"Property 'n.b' was updated. From [complex value] to [complex value]\n" +
+		"Property 'new' was added with value: null"
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+	if !res.Changed || res.Summary.Unchanged != 0 {
+		t.Errorf("Changed = %v, Summary = %+v", res.Changed, res.Summary)
+	}
+
+	if _, err := DiffLargeFiles(context.Background(), left, filepath.Join(dir, "missing.json"), stream.Options{}); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
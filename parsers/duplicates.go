@@ -0,0 +1,324 @@
+package parsers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Options tune ParseContext and ParseFileContext. The zero value enforces no
+// limits and lets the last of repeated JSON keys win.
+type Options struct {
+	Limits
+	// Duplicates selects what a key repeated within one object does.
+	Duplicates DuplicateKeys
+	// OnDuplicate receives every repeated key under WarnDuplicates, in
+	// document order.
+	OnDuplicate func(DuplicateKey)
+}
+
+// DuplicateKeys selects how parsing treats a key repeated within one object.
+// Whatever the mode, a document that parses keeps the last value of the key.
+type DuplicateKeys int
+
+const (
+	// AllowDuplicates ignores repeated keys where the format permits them, as
+	// encoding/json does. YAML forbids them, so a YAML document repeating a
+	// key still fails to decode.
+	AllowDuplicates DuplicateKeys = iota
+	// WarnDuplicates reports each repeat to Options.OnDuplicate.
+	WarnDuplicates
+	// RejectDuplicates fails with *DuplicateKeyError.
+	RejectDuplicates
+)
+
+// DuplicateFinder is implemented by parsers whose format can repeat a key
+// within an object. Parsers that do not implement it are never checked.
+type DuplicateFinder interface {
+	Parser
+	// FindDuplicates lists the repeated keys of a document that parses.
+	FindDuplicates(data []byte) ([]DuplicateKey, error)
+}
+
+// DuplicateKey is one repeat of a key within an object.
+type DuplicateKey struct {
+	// Path leads from the top-level object to the key, the key included;
+	// array elements appear as "[i]".
+	Path []string
+	// Line and Column locate the repeat, FirstLine and FirstColumn the
+	// first occurrence of the key in the same object.
+	Line, Column           int
+	FirstLine, FirstColumn int
+}
+
+// String joins Path the way plain prints properties: a.b[0].c.
+func (d DuplicateKey) String() string {
+	var b strings.Builder
+	for i, p := range d.Path {
+		if i > 0 && !strings.HasPrefix(p, "[") {
+			b.WriteString(".")
+		}
+		b.WriteString(p)
+	}
+	return b.String()
+}
+
+// Warning describes d as found in file, for the warnings of a diff tree.
+func (d DuplicateKey) Warning(file string) string {
+	return fmt.Sprintf("duplicate key %q at %s:%d:%d, first at %d:%d", d.String(), file, d.Line, d.Column, d.FirstLine, d.FirstColumn)
+}
+
+// ErrDuplicateKey matches *DuplicateKeyError.
+var ErrDuplicateKey = errors.New("duplicate key")
+
+// DuplicateKeyError reports the repeated keys of a document parsed with
+// RejectDuplicates.
+type DuplicateKeyError struct {
+	File       string
+	Duplicates []DuplicateKey
+}
+
+func (e *DuplicateKeyError) Error() string {
+	d := e.Duplicates[0]
+	msg := fmt.Sprintf("%s:%d:%d: duplicate key %q, first defined at %d:%d", e.File, d.Line, d.Column, d.String(), d.FirstLine, d.FirstColumn)
+	if n := len(e.Duplicates) - 1; n > 0 {
+		msg += fmt.Sprintf(" (and %d more)", n)
+	}
+	return msg
+}
+
+func (e *DuplicateKeyError) Is(target error) bool {
+	return target == ErrDuplicateKey
+}
+
+// checkDuplicates applies opts.Duplicates to a document p has parsed.
+func checkDuplicates(p Parser, data []byte, name string, opts Options) error {
+	f, ok := p.(DuplicateFinder)
+	if opts.Duplicates == AllowDuplicates || !ok {
+		return nil
+	}
+	dups, err := f.FindDuplicates(data)
+	if err != nil || len(dups) == 0 {
+		return err
+	}
+	if opts.Duplicates == RejectDuplicates {
+		return &DuplicateKeyError{File: name, Duplicates: dups}
+	}
+	if opts.OnDuplicate != nil {
+		for _, d := range dups {
+			opts.OnDuplicate(d)
+		}
+	}
+	return nil
+}
+
+// jsonContainer is an object or array open while findJSONDuplicates walks.
+type jsonContainer struct {
+	label  string // path element of the container itself
+	object bool
+	seen   map[string]int // key to the offset of its first occurrence
+	key    string         // object: the key whose value comes next
+	inKey  bool           // object: expecting a key or the closing brace
+	index  int            // array: index of the next element
+}
+
+func findJSONDuplicates(data []byte) ([]DuplicateKey, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	lines := newLineIndex(data)
+
+	var (
+		stack []*jsonContainer
+		dups  []DuplicateKey
+	)
+	path := func(key string) []string {
+		out := make([]string, 0, len(stack))
+		for _, c := range stack[1:] {
+			out = append(out, c.label)
+		}
+		return append(out, key)
+	}
+	// valueDone moves the innermost container past the value just read.
+	valueDone := func() {
+		if len(stack) == 0 {
+			return
+		}
+		if top := stack[len(stack)-1]; top.object {
+			top.inKey = true
+		} else {
+			top.index++
+		}
+	}
+
+	for {
+		off := dec.InputOffset()
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			return dups, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if n := len(stack); n > 0 && stack[n-1].object && stack[n-1].inKey {
+			top := stack[n-1]
+			key, ok := tok.(string)
+			if !ok { // the closing brace
+				stack = stack[:n-1]
+				valueDone()
+				continue
+			}
+			start := keyStart(data, off)
+			if first, dup := top.seen[key]; dup {
+				d := DuplicateKey{Path: path(key)}
+				d.Line, d.Column = lines.position(start)
+				d.FirstLine, d.FirstColumn = lines.position(first)
+				dups = append(dups, d)
+			} else {
+				top.seen[key] = start
+			}
+			top.key, top.inKey = key, false
+			continue
+		}
+
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			c := &jsonContainer{object: tok == json.Delim('{')}
+			if c.object {
+				c.seen, c.inKey = map[string]int{}, true
+			}
+			if n := len(stack); n > 0 {
+				if parent := stack[n-1]; parent.object {
+					c.label = parent.key
+				} else {
+					c.label = fmt.Sprintf("[%d]", parent.index)
+				}
+			}
+			stack = append(stack, c)
+		case json.Delim(']'):
+			stack = stack[:len(stack)-1]
+			valueDone()
+		default:
+			valueDone()
+		}
+	}
+}
+
+// keyStart finds the opening quote of a key whose token began at off, past
+// the separator and whitespace the decoder had not consumed yet.
+func keyStart(data []byte, off int64) int {
+	i := int(off)
+	for i < len(data) && strings.IndexByte(" \t\r\n,", data[i]) >= 0 {
+		i++
+	}
+	return i
+}
+
+func findYAMLDuplicates(data []byte) ([]DuplicateKey, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	var dups []DuplicateKey
+	walkYAMLMappings(&root, nil, func(n *yaml.Node, path []string) {
+		first := map[string]*yaml.Node{}
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			k := n.Content[i]
+			if !comparableYAMLKey(k) {
+				continue
+			}
+			if f, dup := first[k.Value]; dup {
+				dups = append(dups, DuplicateKey{
+					Path:      append(append([]string(nil), path...), k.Value),
+					Line:      k.Line,
+					Column:    k.Column,
+					FirstLine: f.Line, FirstColumn: f.Column,
+				})
+				continue
+			}
+			first[k.Value] = k
+		}
+	})
+	sort.SliceStable(dups, func(i, j int) bool {
+		return dups[i].Line < dups[j].Line || dups[i].Line == dups[j].Line && dups[i].Column < dups[j].Column
+	})
+	return dups, nil
+}
+
+// dropYAMLDuplicates removes all but the last occurrence of every repeated
+// key, which yaml.v3 would otherwise refuse to decode.
+func dropYAMLDuplicates(root *yaml.Node) {
+	walkYAMLMappings(root, nil, func(n *yaml.Node, _ []string) {
+		last := map[string]int{}
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if k := n.Content[i]; comparableYAMLKey(k) {
+				last[k.Value] = i
+			}
+		}
+		if len(last) == len(n.Content)/2 {
+			return
+		}
+		kept := n.Content[:0]
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			k := n.Content[i]
+			if !comparableYAMLKey(k) || last[k.Value] == i {
+				kept = append(kept, k, n.Content[i+1])
+			}
+		}
+		n.Content = kept
+	})
+}
+
+// comparableYAMLKey leaves out merge keys, which may repeat, and keys that
+// are not scalars.
+func comparableYAMLKey(k *yaml.Node) bool {
+	return k.Kind == yaml.ScalarNode && k.Tag != "!!merge"
+}
+
+// walkYAMLMappings calls fn for every mapping in the tree, aliases aside: an
+// anchored mapping is visited where it is defined.
+func walkYAMLMappings(n *yaml.Node, path []string, fn func(*yaml.Node, []string)) {
+	switch n.Kind {
+	case yaml.DocumentNode:
+		for _, c := range n.Content {
+			walkYAMLMappings(c, path, fn)
+		}
+	case yaml.MappingNode:
+		fn(n, path)
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			walkYAMLMappings(n.Content[i+1], append(path[:len(path):len(path)], n.Content[i].Value), fn)
+		}
+	case yaml.SequenceNode:
+		for i, c := range n.Content {
+			walkYAMLMappings(c, append(path[:len(path):len(path)], fmt.Sprintf("[%d]", i)), fn)
+		}
+	}
+}
+
+// lineIndex converts many byte offsets of one document to positions.
+type lineIndex struct {
+	data   []byte
+	starts []int // offset of the first byte of every line
+}
+
+func newLineIndex(data []byte) lineIndex {
+	starts := []int{0}
+	for i, c := range data {
+		if c == '\n' {
+			starts = append(starts, i+1)
+		}
+	}
+	return lineIndex{data: data, starts: starts}
+}
+
+// position is Position for an offset into the indexed document.
+func (ix lineIndex) position(off int) (line, column int) {
+	line = sort.Search(len(ix.starts), func(i int) bool { return ix.starts[i] > off })
+	return line, utf8.RuneCount(ix.data[ix.starts[line-1]:off]) + 1
+}
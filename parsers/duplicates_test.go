@@ -0,0 +1,93 @@
+package parsers
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFindDuplicates(t *testing.T) {
+	cases := []struct {
+		name, format, doc string
+		want              []DuplicateKey
+	}{
+		{"json none", "json", `{"a":{"b":1},"c":{"b":2},"l":[{"b":1},{"b":2}]}`, nil},
+		{"json top level", "json", "{\n  \"a\": 1,\n  \"a\": 2\n}", []DuplicateKey{
+			{Path: []string{"a"}, Line: 3, Column: 3, FirstLine: 2, FirstColumn: 3},
+		}},
+		{"json nested and in arrays", "json", `{"o":{"k":1,"k":2,"k":3},"l":[1,{"x":[],"x":{}}]}`, []DuplicateKey{
+			{Path: []string{"o", "k"}, Line: 1, Column: 13, FirstLine: 1, FirstColumn: 7},
+			{Path: []string{"o", "k"}, Line: 1, Column: 19, FirstLine: 1, FirstColumn: 7},
+			{Path: []string{"l", "[1]", "x"}, Line: 1, Column: 41, FirstLine: 1, FirstColumn: 34},
+		}},
+		{"json escaped key", "json", `{"\u0061":1,"a":2}`, []DuplicateKey{
+			{Path: []string{"a"}, Line: 1, Column: 13, FirstLine: 1, FirstColumn: 2},
+		}},
+		{"yaml none", "yaml", "a: {b: 1}\nc: {b: 2}\n", nil},
+		{"yaml nested", "yaml", "a:\n  b: 1\n  b: 2\nl:\n  - x: 1\n    x: 2\n", []DuplicateKey{
+			{Path: []string{"a", "b"}, Line: 3, Column: 3, FirstLine: 2, FirstColumn: 3},
+			{Path: []string{"l", "[0]", "x"}, Line: 6, Column: 5, FirstLine: 5, FirstColumn: 5},
+		}},
+		{"yaml merge keys and aliases", "yaml", "base: &b {k: 1, k: 2}\nm:\n  <<: *b\n  <<: {z: 1}\nn: *b\n", []DuplicateKey{
+			{Path: []string{"base", "k"}, Line: 1, Column: 17, FirstLine: 1, FirstColumn: 11},
+		}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, _ := Lookup(tc.format)
+			got, err := p.(DuplicateFinder).FindDuplicates([]byte(tc.doc))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got  %#v\nwant %#v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseContext_Duplicates(t *testing.T) {
+	ctx := context.Background()
+	for _, format := range []string{"json", "yaml"} {
+		doc := []byte(`{"a": {"b": 1, "b": 2}}`)
+
+		got, err := ParseContext(ctx, doc, format, "doc", Options{})
+		switch {
+		case format == "yaml":
+			// YAML forbids repeated keys; only warn and strict look past them
+			if err == nil || !strings.Contains(err.Error(), `mapping key "b" already defined`) {
+				t.Errorf("yaml allow: got %v, want the yaml.v3 error", err)
+			}
+		case err != nil:
+			t.Fatalf("%s allow: %v", format, err)
+		default:
+			if b, _ := getInt(got["a"].(map[string]any)["b"]); b != 2 {
+				t.Errorf("%s allow: last value should win, got %#v", format, got)
+			}
+		}
+
+		var warned []string
+		_, err = ParseContext(ctx, doc, format, "doc", Options{
+			Duplicates:  WarnDuplicates,
+			OnDuplicate: func(d DuplicateKey) { warned = append(warned, d.String()) },
+		})
+		if err != nil || !reflect.DeepEqual(warned, []string{"a.b"}) {
+			t.Errorf("%s warn: got %v, %v", format, warned, err)
+		}
+
+		_, err = ParseContext(ctx, doc, format, "doc", Options{Duplicates: RejectDuplicates})
+		var dupErr *DuplicateKeyError
+		if !errors.As(err, &dupErr) || !errors.Is(err, ErrDuplicateKey) || dupErr.File != "doc" || len(dupErr.Duplicates) != 1 {
+			t.Errorf("%s strict: got %v, want *DuplicateKeyError", format, err)
+		}
+	}
+}
+
+func TestDuplicateKey_String(t *testing.T) {
+	d := DuplicateKey{Path: []string{"a", "[0]", "[2]", "b"}}
+	if got := d.String(); got != "a[0][2].b" {
+		t.Errorf("String() = %q", got)
+	}
+}
@@ -0,0 +1,62 @@
+package parsers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrUnsupportedFormat matches *UnsupportedFormatError.
+	ErrUnsupportedFormat = errors.New("unsupported format")
+	// ErrSyntax matches *SyntaxError.
+	ErrSyntax = errors.New("syntax error")
+	// ErrLimitExceeded matches every error reporting a broken Limits field.
+	ErrLimitExceeded = errors.New("limit exceeded")
+)
+
+// UnsupportedFormatError reports that no parser is registered for a format
+// name, MIME type or, when Extension is set, for a file extension.
+type UnsupportedFormatError struct {
+	Format    string
+	Extension bool
+}
+
+func (e *UnsupportedFormatError) Error() string {
+	if e.Extension {
+		return "unsupported file extension: " + e.Format
+	}
+	return "unsupported format: " + e.Format
+}
+
+func (e *UnsupportedFormatError) Is(target error) bool {
+	return target == ErrUnsupportedFormat
+}
+
+// SyntaxError reports a malformed document. Line and Column are 1-based and
+// zero when unknown; YAML errors usually know the line only.
+type SyntaxError struct {
+	// Format is the parser that failed, such as "json".
+	Format string
+	File   string
+	Line   int
+	Column int
+	Err    error
+}
+
+func (e *SyntaxError) Error() string {
+	loc := e.File
+	switch {
+	case e.Line > 0 && e.Column > 0:
+		loc = fmt.Sprintf("%s:%d:%d", e.File, e.Line, e.Column)
+	case e.Line > 0:
+		loc = fmt.Sprintf("%s:%d", e.File, e.Line)
+	}
+	return fmt.Sprintf("%s: invalid %s: %v", loc, strings.ToUpper(e.Format), e.Err)
+}
+
+func (e *SyntaxError) Unwrap() error { return e.Err }
+
+func (e *SyntaxError) Is(target error) bool {
+	return target == ErrSyntax
+}
@@ -0,0 +1,47 @@
+package parsers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestErrors_LimitSentinel(t *testing.T) {
+	for _, err := range []error{
+		&SizeLimitError{Size: 2, Limit: 1},
+		&DepthLimitError{Limit: 1},
+		&NodeLimitError{Limit: 1},
+		&AliasLimitError{Limit: 1},
+	} {
+		wrapped := fmt.Errorf("parse %q: %w", "x.yaml", err)
+		if !errors.Is(wrapped, ErrLimitExceeded) {
+			t.Errorf("%T does not match ErrLimitExceeded", err)
+		}
+		if errors.Is(wrapped, ErrSyntax) || errors.Is(wrapped, ErrUnsupportedFormat) {
+			t.Errorf("%T matches an unrelated sentinel", err)
+		}
+	}
+}
+
+func TestSyntaxError_Error(t *testing.T) {
+	err := &SyntaxError{Format: "json", File: "a.json", Line: 3, Column: 7, Err: errors.New("unexpected end of JSON input")}
+	if got, want := err.Error(), "a.json:3:7: invalid JSON: unexpected end of JSON input"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+	err.Line, err.Column = 0, 0
+	if got, want := err.Error(), "a.json: invalid JSON: unexpected end of JSON input"; got != want {
+		t.Errorf("Error() without position = %q, want %q", got, want)
+	}
+}
+
+func TestParseContext_UnsupportedFormat(t *testing.T) {
+	_, err := ParseContext(context.Background(), []byte(`{}`), "", "conf.ini", Options{})
+	var unsupported *UnsupportedFormatError
+	if !errors.As(err, &unsupported) || unsupported.Format != ".ini" || !errors.Is(err, ErrUnsupportedFormat) {
+		t.Fatalf("got %v, want *UnsupportedFormatError for .ini", err)
+	}
+	if err.Error() != "unsupported file extension: .ini" {
+		t.Errorf("message changed: %q", err.Error())
+	}
+}
@@ -0,0 +1,365 @@
+package parsers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Limits bounds the work a single document may cause. Zero fields are unlimited.
+type Limits struct {
+	// MaxBytes caps the size of the raw input.
+	MaxBytes int64
+	// MaxDepth caps the nesting of objects and arrays; the top-level object is depth 1.
+	MaxDepth int
+	// MaxNodes caps the number of values: every object, array and scalar,
+	// counted after YAML aliases are expanded. Keys are not counted.
+	MaxNodes int
+	// MaxAliases caps YAML alias expansions, including those inside aliased
+	// nodes, so an alias bomb is rejected before it is expanded.
+	MaxAliases int
+}
+
+// DefaultLimits are the limits used by the CLI and the HTTP server: generous
+// for real configuration files, far below what an alias bomb expands to.
+func DefaultLimits() Limits {
+	return Limits{
+		MaxBytes:   64 << 20,
+		MaxDepth:   64,
+		MaxNodes:   1_000_000,
+		MaxAliases: 10_000,
+	}
+}
+
+func (l Limits) structural() bool {
+	return l.MaxDepth > 0 || l.MaxNodes > 0 || l.MaxAliases > 0
+}
+
+// SizeLimitError, DepthLimitError, NodeLimitError and AliasLimitError each
+// report one exceeded Limits field; all of them match ErrLimitExceeded.
+type SizeLimitError struct {
+	// Size is zero when only part of the input was read, as from a pipe.
+	Size  int64
+	Limit int64
+}
+
+func (e *SizeLimitError) Error() string {
+	if e.Size <= 0 {
+		return fmt.Sprintf("input of more than %d bytes exceeds the limit", e.Limit)
+	}
+	return fmt.Sprintf("input of %d bytes exceeds the %d byte limit", e.Size, e.Limit)
+}
+
+func (e *SizeLimitError) Is(target error) bool { return target == ErrLimitExceeded }
+
+type DepthLimitError struct {
+	Limit int
+}
+
+func (e *DepthLimitError) Error() string {
+	return fmt.Sprintf("nesting exceeds the depth limit of %d", e.Limit)
+}
+
+func (e *DepthLimitError) Is(target error) bool { return target == ErrLimitExceeded }
+
+type NodeLimitError struct {
+	Limit int
+}
+
+func (e *NodeLimitError) Error() string {
+	return fmt.Sprintf("document exceeds the limit of %d values", e.Limit)
+}
+
+func (e *NodeLimitError) Is(target error) bool { return target == ErrLimitExceeded }
+
+type AliasLimitError struct {
+	Limit int
+}
+
+func (e *AliasLimitError) Error() string {
+	return fmt.Sprintf("document exceeds the limit of %d YAML alias expansions", e.Limit)
+}
+
+func (e *AliasLimitError) Is(target error) bool { return target == ErrLimitExceeded }
+
+// LimitedParser is implemented by parsers that enforce Options while
+// decoding, before the document is built in memory. Documents from other
+// parsers are checked once decoded.
+type LimitedParser interface {
+	Parser
+	ParseLimited(ctx context.Context, data []byte, name string, opts Options) (map[string]any, error)
+}
+
+// ParseContext decodes data with the parser for format, or for the extension
+// of name when format is empty, enforcing opts and stopping when ctx is done.
+func ParseContext(ctx context.Context, data []byte, format, name string, opts Options) (map[string]any, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if opts.MaxBytes > 0 && int64(len(data)) > opts.MaxBytes {
+		return nil, &SizeLimitError{Size: int64(len(data)), Limit: opts.MaxBytes}
+	}
+
+	p, err := resolve(format, name)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc map[string]any
+	if lp, ok := p.(LimitedParser); ok {
+		if doc, err = lp.ParseLimited(ctx, data, name, opts); err != nil {
+			return nil, err
+		}
+	} else {
+		if doc, err = p.Parse(data, name); err != nil {
+			return nil, err
+		}
+		if err := checkLimits(ctx, doc, opts.Limits); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := checkDuplicates(p, data, name, opts); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// ParseFileContext is ParseFileAs with options and cancellation; a file larger
+// than opts.MaxBytes is rejected without being read in full.
+func ParseFileContext(ctx context.Context, path, format string, opts Options) (map[string]any, error) {
+	parsed, err := parseFile(ctx, path, format, opts)
+	if err != nil {
+		return nil, fmt.Errorf("parse %q: %w", path, err)
+	}
+	return parsed, nil
+}
+
+func parseFile(ctx context.Context, path, format string, opts Options) (map[string]any, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("abs(%q): %w", path, err)
+	}
+
+	data, err := readFile(abs, opts.MaxBytes)
+	if err != nil {
+		return nil, err
+	}
+	return ParseContext(ctx, data, format, abs, opts)
+}
+
+func readFile(abs string, maxBytes int64) ([]byte, error) {
+	if maxBytes <= 0 {
+		data, err := os.ReadFile(abs)
+		if err != nil {
+			return nil, fmt.Errorf("read %q: %w", abs, err)
+		}
+		return data, nil
+	}
+
+	f, err := os.Open(abs)
+	if err != nil {
+		return nil, fmt.Errorf("read %q: %w", abs, err)
+	}
+	defer f.Close()
+
+	data, err := ReadLimited(f, maxBytes)
+	if err != nil {
+		return nil, fmt.Errorf("read %q: %w", abs, err)
+	}
+	return data, nil
+}
+
+// ReadLimited reads r to the end, failing with *SizeLimitError once more than
+// maxBytes arrive; zero means no limit.
+func ReadLimited(r io.Reader, maxBytes int64) ([]byte, error) {
+	if maxBytes <= 0 {
+		return io.ReadAll(r)
+	}
+	data, err := io.ReadAll(io.LimitReader(r, maxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > maxBytes {
+		return nil, &SizeLimitError{Size: fileSize(r), Limit: maxBytes}
+	}
+	return data, nil
+}
+
+// fileSize is the size of r when it is a regular file, and 0 otherwise: the
+// reader stopped one byte past the limit, so that is all that is known.
+func fileSize(r io.Reader) int64 {
+	f, ok := r.(interface{ Stat() (fs.FileInfo, error) })
+	if !ok {
+		return 0
+	}
+	info, err := f.Stat()
+	if err != nil || !info.Mode().IsRegular() {
+		return 0
+	}
+	return info.Size()
+}
+
+// checkLimits walks a decoded document; ctx is polled every few thousand values.
+func checkLimits(ctx context.Context, doc map[string]any, limits Limits) error {
+	if limits.MaxDepth <= 0 && limits.MaxNodes <= 0 {
+		return ctx.Err()
+	}
+
+	nodes := 0
+	var walk func(v any, depth int) error
+	walk = func(v any, depth int) error {
+		nodes++
+		if limits.MaxNodes > 0 && nodes > limits.MaxNodes {
+			return &NodeLimitError{Limit: limits.MaxNodes}
+		}
+		if nodes%4096 == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+
+		switch x := v.(type) {
+		case map[string]any:
+			if limits.MaxDepth > 0 && depth > limits.MaxDepth {
+				return &DepthLimitError{Limit: limits.MaxDepth}
+			}
+			for _, vv := range x {
+				if err := walk(vv, depth+1); err != nil {
+					return err
+				}
+			}
+		case []any:
+			if limits.MaxDepth > 0 && depth > limits.MaxDepth {
+				return &DepthLimitError{Limit: limits.MaxDepth}
+			}
+			for _, vv := range x {
+				if err := walk(vv, depth+1); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	return walk(doc, 1)
+}
+
+// yamlCost is what a YAML node expands to once aliases are resolved.
+type yamlCost struct {
+	nodes   int
+	depth   int
+	aliases int
+}
+
+// maxCost keeps sums of exponential alias fan-out from overflowing.
+const maxCost = 1 << 40
+
+func addCost(a, b int) int {
+	if a > maxCost-b {
+		return maxCost
+	}
+	return a + b
+}
+
+// yamlChecker measures the expanded size of a yaml.Node tree without
+// expanding it: the cost of every anchored node is computed once and reused at
+// each alias, so an alias bomb takes time linear in its source text.
+type yamlChecker struct {
+	ctx    context.Context
+	limits Limits
+	memo   map[*yaml.Node]yamlCost
+	active map[*yaml.Node]bool
+}
+
+func checkYAML(ctx context.Context, root *yaml.Node, limits Limits) error {
+	if !limits.structural() {
+		return ctx.Err()
+	}
+	c := yamlChecker{ctx: ctx, limits: limits, memo: map[*yaml.Node]yamlCost{}, active: map[*yaml.Node]bool{}}
+	_, err := c.cost(root, 0)
+	return err
+}
+
+func (c *yamlChecker) cost(n *yaml.Node, depth int) (yamlCost, error) {
+	if err := c.ctx.Err(); err != nil {
+		return yamlCost{}, err
+	}
+	if n.Kind == yaml.DocumentNode {
+		if len(n.Content) == 0 {
+			return yamlCost{}, nil
+		}
+		return c.cost(n.Content[0], depth)
+	}
+	if n.Kind == yaml.AliasNode {
+		target, err := c.anchored(n.Alias, depth)
+		if err != nil {
+			return yamlCost{}, err
+		}
+		target.aliases = addCost(target.aliases, 1)
+		return target, c.check(target, depth)
+	}
+	if n.Anchor != "" {
+		return c.anchored(n, depth)
+	}
+	return c.expand(n, depth)
+}
+
+// anchored measures an anchored node once, relative to its own position.
+func (c *yamlChecker) anchored(n *yaml.Node, depth int) (yamlCost, error) {
+	if cost, ok := c.memo[n]; ok {
+		return cost, nil
+	}
+	if c.active[n] {
+		return yamlCost{}, fmt.Errorf("anchor %q contains an alias to itself", n.Anchor)
+	}
+	c.active[n] = true
+	cost, err := c.expand(n, 0)
+	delete(c.active, n)
+	if err != nil {
+		return yamlCost{}, err
+	}
+	c.memo[n] = cost
+	return cost, c.check(cost, depth)
+}
+
+func (c *yamlChecker) expand(n *yaml.Node, depth int) (yamlCost, error) {
+	total := yamlCost{nodes: 1}
+	if n.Kind != yaml.MappingNode && n.Kind != yaml.SequenceNode {
+		return total, nil
+	}
+
+	deepest := 0
+	for i, child := range n.Content {
+		if n.Kind == yaml.MappingNode && i%2 == 0 {
+			continue
+		}
+		cost, err := c.cost(child, depth+1)
+		if err != nil {
+			return yamlCost{}, err
+		}
+		total.nodes = addCost(total.nodes, cost.nodes)
+		total.aliases = addCost(total.aliases, cost.aliases)
+		deepest = max(deepest, cost.depth)
+	}
+	total.depth = deepest + 1
+	return total, c.check(total, depth)
+}
+
+// check tests a subtree's cost as if it sat depth levels below the root.
+func (c *yamlChecker) check(cost yamlCost, depth int) error {
+	l := c.limits
+	switch {
+	case l.MaxAliases > 0 && cost.aliases > l.MaxAliases:
+		return &AliasLimitError{Limit: l.MaxAliases}
+	case l.MaxNodes > 0 && cost.nodes > l.MaxNodes:
+		return &NodeLimitError{Limit: l.MaxNodes}
+	case l.MaxDepth > 0 && depth+cost.depth > l.MaxDepth:
+		return &DepthLimitError{Limit: l.MaxDepth}
+	}
+	return nil
+}
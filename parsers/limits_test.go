@@ -0,0 +1,128 @@
+package parsers
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// billionLaughs expands to 9^9 strings from a few hundred bytes.
+const billionLaughs = `a: &a ["lol","lol","lol","lol","lol","lol","lol","lol","lol"]
+b: &b [*a,*a,*a,*a,*a,*a,*a,*a,*a]
+c: &c [*b,*b,*b,*b,*b,*b,*b,*b,*b]
+d: &d [*c,*c,*c,*c,*c,*c,*c,*c,*c]
+e: &e [*d,*d,*d,*d,*d,*d,*d,*d,*d]
+f: &f [*e,*e,*e,*e,*e,*e,*e,*e,*e]
+g: &g [*f,*f,*f,*f,*f,*f,*f,*f,*f]
+h: &h [*g,*g,*g,*g,*g,*g,*g,*g,*g]
+i: &i [*h,*h,*h,*h,*h,*h,*h,*h,*h]
+`
+
+func nested(format string, depth int) string {
+	if format == "json" {
+		return strings.Repeat(`{"k":`, depth) + `{"v":1}` + strings.Repeat("}", depth)
+	}
+	var b strings.Builder
+	for i := 0; i < depth; i++ {
+		b.WriteString(strings.Repeat("  ", i) + "k:\n")
+	}
+	b.WriteString(strings.Repeat("  ", depth) + "v: 1\n")
+	return b.String()
+}
+
+func TestParseContext_AliasBomb(t *testing.T) {
+	start := time.Now()
+	_, err := ParseContext(context.Background(), []byte(billionLaughs), "yaml", "bomb.yaml", Options{Limits: Limits{MaxAliases: 1000}})
+
+	var aliasErr *AliasLimitError
+	if !errors.As(err, &aliasErr) || aliasErr.Limit != 1000 {
+		t.Fatalf("got %v, want *AliasLimitError", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("rejecting the bomb took %v", elapsed)
+	}
+
+	_, err = ParseContext(context.Background(), []byte(billionLaughs), "yaml", "bomb.yaml", Options{Limits: Limits{MaxNodes: 10000}})
+	var nodeErr *NodeLimitError
+	if !errors.As(err, &nodeErr) {
+		t.Fatalf("MaxNodes: got %v, want *NodeLimitError", err)
+	}
+}
+
+func TestParseContext_Depth(t *testing.T) {
+	for _, format := range []string{"json", "yaml"} {
+		doc := []byte(nested(format, 10))
+		// 10 nested objects under the top-level one
+		if _, err := ParseContext(context.Background(), doc, format, "doc", Options{Limits: Limits{MaxDepth: 11}}); err != nil {
+			t.Errorf("%s: depth 11 within limit: %v", format, err)
+		}
+
+		_, err := ParseContext(context.Background(), doc, format, "doc", Options{Limits: Limits{MaxDepth: 10}})
+		var depthErr *DepthLimitError
+		if !errors.As(err, &depthErr) || depthErr.Limit != 10 {
+			t.Errorf("%s: got %v, want *DepthLimitError", format, err)
+		}
+	}
+}
+
+func TestParseContext_Nodes(t *testing.T) {
+	doc := []byte(`{"a":[1,2,3],"b":{"c":true}}`)
+	// root, a, 1, 2, 3, b, c
+	if _, err := ParseContext(context.Background(), doc, "json", "doc", Options{Limits: Limits{MaxNodes: 7}}); err != nil {
+		t.Fatalf("7 values within limit: %v", err)
+	}
+	_, err := ParseContext(context.Background(), doc, "json", "doc", Options{Limits: Limits{MaxNodes: 6}})
+	var nodeErr *NodeLimitError
+	if !errors.As(err, &nodeErr) {
+		t.Fatalf("got %v, want *NodeLimitError", err)
+	}
+}
+
+func TestParseContext_SizeAndCancel(t *testing.T) {
+	_, err := ParseContext(context.Background(), []byte(`{"a":1}`), "json", "doc", Options{Limits: Limits{MaxBytes: 4}})
+	var sizeErr *SizeLimitError
+	if !errors.As(err, &sizeErr) || sizeErr.Size != 7 || sizeErr.Limit != 4 {
+		t.Fatalf("got %v, want *SizeLimitError{7, 4}", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "big.json")
+	if err := os.WriteFile(path, []byte(`{"a":"`+strings.Repeat("x", 100)+`"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseFileContext(context.Background(), path, "", Options{Limits: Limits{MaxBytes: 50}}); !errors.As(err, &sizeErr) || sizeErr.Size != 108 {
+		t.Fatalf("file: got %v, want *SizeLimitError of the whole 108 bytes", err)
+	}
+	_, err = ReadLimited(strings.NewReader(strings.Repeat("x", 100)), 50)
+	if !errors.As(err, &sizeErr) || err.Error() != "input of more than 50 bytes exceeds the limit" {
+		t.Fatalf("reader: got %v, want the size left unknown", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ParseContext(ctx, []byte(`{}`), "json", "doc", Options{}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("canceled: got %v", err)
+	}
+}
+
+func TestParseContext_AliasesWithinLimits(t *testing.T) {
+	doc := []byte("base: &base {x: 1}\nuse: *base\nmerged:\n  <<: *base\n  y: 2\n")
+	got, err := ParseContext(context.Background(), doc, "yaml", "doc", Options{Limits: DefaultLimits()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got["merged"].(map[string]any)["x"] != 1 {
+		t.Fatalf("merge key not applied: %#v", got)
+	}
+}
+
+func TestParseContext_RecursiveAlias(t *testing.T) {
+	for _, doc := range []string{"a: &a [*a]\n", "a: &a {b: *a}\n"} {
+		if _, err := ParseContext(context.Background(), []byte(doc), "yaml", "doc", Options{Limits: DefaultLimits()}); err == nil {
+			t.Errorf("%q: expected an error", doc)
+		}
+	}
+}
@@ -2,60 +2,68 @@ package parsers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"gopkg.in/yaml.v3"
-	"os"
+	"io"
 	"path/filepath"
 )
 
 func ParseFiles(paths ...string) ([]map[string]any, error) {
 	res := make([]map[string]any, 0, len(paths))
 	for _, p := range paths {
-		parsed, err := parseFile(p)
+		parsed, err := ParseFileContext(context.Background(), p, "", Options{})
 		if err != nil {
-			return nil, fmt.Errorf("parse %q: %w", p, err)
+			return nil, err
 		}
 		res = append(res, parsed)
 	}
 	return res, nil
 }
 
-func parseFile(path string) (map[string]any, error) {
-	abs, err := filepath.Abs(path)
-	if err != nil {
-		return nil, fmt.Errorf("abs(%q): %w", path, err)
-	}
+// ParseFileAs parses path with the parser for format (a name, MIME type or
+// extension) instead of the one its extension selects; an empty format keeps
+// the extension.
+func ParseFileAs(path, format string) (map[string]any, error) {
+	return ParseFileContext(context.Background(), path, format, Options{})
+}
 
-	dst := map[string]any{}
-	data, err := os.ReadFile(abs)
-	if err != nil {
-		return nil, fmt.Errorf("read %q: %w", abs, err)
-	}
+// ParseBytes decodes an in-memory document; name only selects the format by
+// extension and labels errors.
+func ParseBytes(data []byte, name string) (map[string]any, error) {
+	return ParseContext(context.Background(), data, "", name, Options{})
+}
 
-	switch ext := filepath.Ext(abs); ext {
-	case ".json":
-		if err := parseJSON(dst, data, abs); err != nil {
-			return nil, err
-		}
-		normalizeJSONNumbersAny(dst)
-	case ".yaml", ".yml":
-		if err := parseYAML(dst, data, abs); err != nil {
-			return nil, err
+// ParseFormat decodes data with the parser registered for format, given as a
+// name ("json"), a MIME type or an extension.
+func ParseFormat(data []byte, format, name string) (map[string]any, error) {
+	return ParseContext(context.Background(), data, format, name, Options{})
+}
+
+// resolve picks the parser for format, or by the extension of name.
+func resolve(format, name string) (Parser, error) {
+	if format == "" {
+		ext := filepath.Ext(name)
+		p, ok := ByExtension(ext)
+		if !ok {
+			return nil, &UnsupportedFormatError{Format: ext, Extension: true}
 		}
-	default:
-		return nil, fmt.Errorf("unsupported file extension: %s", ext)
+		return p, nil
 	}
-
-	return dst, nil
+	p, ok := Lookup(format)
+	if !ok {
+		return nil, &UnsupportedFormatError{Format: format}
+	}
+	return p, nil
 }
 
 func parseJSON(dst map[string]any, data []byte, abs string) error {
-	dec := json.NewDecoder(bytes.NewReader(data))
-	dec.UseNumber()
-
-	var tmp map[string]any
-	if err := dec.Decode(&tmp); err != nil {
+	tmp, err := decodeJSON(bytes.NewReader(data))
+	if err != nil {
+		if syntaxErr := jsonSyntaxError(bytes.NewReader(data), abs, err); syntaxErr != nil {
+			return syntaxErr
+		}
 		return fmt.Errorf("json decode %q: %w", abs, err)
 	}
 	tmp = normalizeJSONNumbersAny(tmp).(map[string]any)
@@ -63,13 +71,38 @@ func parseJSON(dst map[string]any, data []byte, abs string) error {
 	return nil
 }
 
-func parseYAML(dst map[string]any, data []byte, abs string) error {
+func decodeJSON(r io.Reader) (map[string]any, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
 	var tmp map[string]any
-	if err := yaml.Unmarshal(data, &tmp); err != nil {
+	err := dec.Decode(&tmp)
+	return tmp, err
+}
+
+// parseYAML decodes into a yaml.Node first, where aliases are still
+// references, so limits are checked before anything is expanded.
+func parseYAML(ctx context.Context, dst map[string]any, data []byte, abs string, opts Options) error {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return yamlSyntaxError(data, abs, err)
+	}
+	if err := checkYAML(ctx, &root, opts.Limits); err != nil {
 		return fmt.Errorf("yaml decode %q: %w", abs, err)
 	}
+	// YAML forbids repeated keys, and yaml.v3 refuses them unless asked to
+	// warn about or reject them, which checkDuplicates does afterwards.
+	if opts.Duplicates != AllowDuplicates {
+		dropYAMLDuplicates(&root)
+	}
 
-	tmp = normalizeJSONNumbersAny(tmp).(map[string]any)
+	var tmp map[string]any
+	if root.Kind != 0 {
+		if err := root.Decode(&tmp); err != nil {
+			return fmt.Errorf("yaml decode %q: %w", abs, err)
+		}
+	}
+
+	tmp, _ = normalizeJSONNumbersAny(tmp).(map[string]any)
 	deepMerge(dst, tmp)
 	return nil
 }
@@ -88,6 +121,12 @@ func deepMerge(dst, src map[string]any) {
 	}
 }
 
+// NormalizeNumbers converts the json.Number values of a UseNumber decode into
+// the int64 or float64 the parsers produce, in place for maps and slices.
+func NormalizeNumbers(v any) any {
+	return normalizeJSONNumbersAny(v)
+}
+
 func normalizeJSONNumbersAny(v any) any {
 	switch x := v.(type) {
 	case json.Number:
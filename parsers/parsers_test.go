@@ -1,6 +1,7 @@
 package parsers
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"io/fs"
@@ -35,8 +36,13 @@ func TestParseFile_JSONDecodeError(t *testing.T) {
 	if err := os.WriteFile(p, []byte(`{"a":`), 0o644); err != nil {
 		t.Fatal(err)
 	}
-	if _, err := parseFile(p); err == nil {
-		t.Fatalf("expected json decode error, got nil")
+	_, err := parseFile(context.Background(), p, "", Options{})
+	var syntaxErr *SyntaxError
+	if !errors.As(err, &syntaxErr) || syntaxErr.Format != "json" || syntaxErr.File != p {
+		t.Fatalf("expected json *SyntaxError, got %v", err)
+	}
+	if !errors.Is(err, ErrSyntax) {
+		t.Fatalf("errors.Is(%v, ErrSyntax) = false", err)
 	}
 }
 
@@ -49,8 +55,10 @@ func TestParseFile_YAMLDecodeError(t *testing.T) {
 	if err := os.WriteFile(p, []byte(": bad"), 0o644); err != nil {
 		t.Fatal(err)
 	}
-	if _, err := parseFile(p); err == nil {
-		t.Fatalf("expected yaml decode error, got nil")
+	_, err := parseFile(context.Background(), p, "", Options{})
+	var syntaxErr *SyntaxError
+	if !errors.As(err, &syntaxErr) || syntaxErr.Format != "yaml" {
+		t.Fatalf("expected yaml *SyntaxError, got %v", err)
 	}
 }
 
@@ -58,7 +66,7 @@ func TestParseFile_AbsError(t *testing.T) {
 	t.Parallel()
 
 	bad := "invalid\x00path.json"
-	if _, err := parseFile(bad); err == nil {
+	if _, err := parseFile(context.Background(), bad, "", Options{}); err == nil {
 		t.Fatalf("expected error for path with NUL byte, got nil")
 	}
 }
@@ -91,7 +99,7 @@ func TestParseFile_RelativePath(t *testing.T) {
 	}
 
 	rel := filepath.Join("a", "b", "..", "b", "config.json")
-	got, err := parseFile(rel)
+	got, err := parseFile(context.Background(), rel, "", Options{})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -110,7 +118,7 @@ func TestParseFile_AbsolutePath(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	got, err := parseFile(full)
+	got, err := parseFile(context.Background(), full, "", Options{})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -129,7 +137,7 @@ func TestParseFile_NotExists(t *testing.T) {
 	t.Parallel()
 
 	dir := t.TempDir()
-	_, err := parseFile(filepath.Join(dir, "missing.json"))
+	_, err := parseFile(context.Background(), filepath.Join(dir, "missing.json"), "", Options{})
 	if err == nil {
 		t.Fatalf("expected error, got nil")
 	}
@@ -243,7 +251,7 @@ nested:
 		t.Fatal(err)
 	}
 
-	got, err := parseFile(p)
+	got, err := parseFile(context.Background(), p, "", Options{})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -0,0 +1,183 @@
+package parsers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Position converts offset, a byte count into what r yields, to a 1-based
+// line and column. Columns count runes, so a caret drawn under the source
+// lines up for UTF-8 text. Reading stops at offset or at the end of r.
+func Position(r io.Reader, offset int64) (line, column int, err error) {
+	br := bufio.NewReader(r)
+	line, column = 1, 1
+	for read := int64(0); read < offset; {
+		c, size, err := br.ReadRune()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return 0, 0, err
+		}
+		read += int64(size)
+		if c == '\n' {
+			line++
+			column = 1
+		} else {
+			column++
+		}
+	}
+	return line, column, nil
+}
+
+// LocateJSONError turns err, from decoding the JSON document in r, into a
+// *SyntaxError at the byte that broke it, or returns nil when err is not a
+// syntax error. Only what it needs is read again: up to the failure, or to the
+// end for a truncated document, in a fixed-size buffer. Decoders that read
+// token by token use it for the errors they already have.
+func LocateJSONError(r io.ReaderAt, name string, err error) *SyntaxError {
+	return jsonSyntaxError(r, name, err)
+}
+
+// jsonSyntaxError locates a failed decode of r: a *json.SyntaxError at the
+// byte that broke it, a truncated document just past its last token. Other
+// errors, such as a top-level array, are not syntax errors and yield nil.
+func jsonSyntaxError(r io.ReaderAt, abs string, err error) *SyntaxError {
+	var (
+		offset    int64
+		syntaxErr *json.SyntaxError
+	)
+	switch {
+	case errors.As(err, &syntaxErr):
+		// Offset counts the offending byte too.
+		offset = max(syntaxErr.Offset-1, 0)
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		end, readErr := contentEnd(io.NewSectionReader(r, 0, math.MaxInt64))
+		if readErr != nil {
+			return nil
+		}
+		offset = end
+	case errors.Is(err, io.EOF):
+		err = errors.New("empty document")
+	default:
+		return nil
+	}
+	line, column, posErr := Position(io.NewSectionReader(r, 0, offset), offset)
+	if posErr != nil {
+		return nil
+	}
+	return &SyntaxError{Format: "json", File: abs, Line: line, Column: column, Err: err}
+}
+
+// contentEnd returns the offset just past the last byte of r that is not JSON
+// whitespace.
+func contentEnd(r io.Reader) (int64, error) {
+	var (
+		end, off int64
+		buf      = make([]byte, 32*1024)
+	)
+	for {
+		n, err := r.Read(buf)
+		for i, c := range buf[:n] {
+			if c != ' ' && c != '\t' && c != '\r' && c != '\n' {
+				end = off + int64(i) + 1
+			}
+		}
+		off += int64(n)
+		if errors.Is(err, io.EOF) {
+			return end, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+}
+
+var (
+	yamlErrorPrefix  = regexp.MustCompile(`^yaml: (?:line (\d+): )?`)
+	yamlUnknownAlias = regexp.MustCompile(`^unknown anchor '(.*)' referenced$`)
+)
+
+// yamlMessage splits a yaml.v3 error into its message and the line it names,
+// or 0 when it names none.
+func yamlMessage(err error) (string, int) {
+	msg := err.Error()
+	m := yamlErrorPrefix.FindStringSubmatch(msg)
+	if m == nil {
+		return msg, 0
+	}
+	line, _ := strconv.Atoi(m[1])
+	return msg[len(m[0]):], line
+}
+
+// yamlSyntaxError turns a yaml.v3 error into a positioned *SyntaxError.
+// yaml.v3 names the line the failing construct began on, counting from 0 or 1
+// depending on the stage that failed, and no column. The line is therefore
+// found again by parsing prefixes of data, and the column left unknown; only
+// an alias to an unknown anchor is located exactly.
+func yamlSyntaxError(data []byte, abs string, err error) *SyntaxError {
+	msg, reported := yamlMessage(err)
+	if m := yamlUnknownAlias.FindStringSubmatch(msg); m != nil {
+		if i := bytes.Index(data, []byte("*"+m[1])); i >= 0 {
+			line, column, _ := Position(bytes.NewReader(data), int64(i))
+			return &SyntaxError{Format: "yaml", File: abs, Line: line, Column: column, Err: errors.New(msg)}
+		}
+	}
+	line := yamlErrorLine(data, msg, reported)
+	return &SyntaxError{Format: "yaml", File: abs, Line: line, Err: errors.New(msg)}
+}
+
+// yamlErrorLine returns the first line at which a prefix of data fails with
+// msg: the line where the parser ran into the problem. The search starts at
+// the line yaml.v3 reported, which never lies past it, and gallops forward
+// before bisecting, so it parses O(log n) prefixes.
+func yamlErrorLine(data []byte, msg string, reported int) int {
+	var ends []int // offset just past every line
+	for i, c := range data {
+		if c == '\n' {
+			ends = append(ends, i+1)
+		}
+	}
+	if len(ends) == 0 || ends[len(ends)-1] != len(data) {
+		ends = append(ends, len(data))
+	}
+	fails := func(lines int) bool {
+		var root yaml.Node
+		err := yaml.Unmarshal(data[:ends[lines-1]], &root)
+		if err == nil {
+			return false
+		}
+		got, _ := yamlMessage(err)
+		return got == msg
+	}
+
+	// Whole lines up to lo parse, or fail differently; data as a whole fails
+	// with msg.
+	n := len(ends)
+	lo := min(max(reported-1, 0), n-1)
+	hi := n
+	for step := 1; lo+step < n; step *= 2 {
+		if fails(lo + step) {
+			hi = lo + step
+			break
+		}
+		lo += step
+	}
+	for hi-lo > 1 {
+		mid := lo + (hi-lo)/2
+		if fails(mid) {
+			hi = mid
+		} else {
+			lo = mid
+		}
+	}
+	return hi
+}
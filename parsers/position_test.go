@@ -0,0 +1,66 @@
+package parsers
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestPosition(t *testing.T) {
+	src := "ab\ncé\nx"
+	cases := []struct {
+		offset    int64
+		line, col int
+	}{
+		{0, 1, 1},
+		{2, 1, 3},
+		{3, 2, 1},
+		{6, 2, 3}, // after the two-byte é
+		{7, 3, 1},
+		{100, 3, 2},
+	}
+	for _, tc := range cases {
+		line, col, err := Position(strings.NewReader(src), tc.offset)
+		if err != nil || line != tc.line || col != tc.col {
+			t.Errorf("Position(%d) = %d:%d, %v; want %d:%d", tc.offset, line, col, err, tc.line, tc.col)
+		}
+	}
+}
+
+func TestParseContext_SyntaxErrorPosition(t *testing.T) {
+	cases := []struct {
+		name, format, doc string
+		line, col         int
+		msg               string
+	}{
+		{"json bad value", "json", "{\n  \"a\": 1,\n  \"b\": }\n", 3, 8, "invalid character '}'"},
+		{"json missing comma", "json", `{"a": 1 "b": 2}`, 1, 9, "after object key:value pair"},
+		{"json truncated", "json", "{\n  \"a\": [1,\n", 2, 11, "unexpected EOF"},
+		{"json empty", "json", "", 1, 1, "empty document"},
+		{"yaml first line", "yaml", ": bad\n", 1, 0, "did not find expected key"},
+		{"yaml scanner", "yaml", "a: 1\n b: 2\n", 2, 0, "mapping values are not allowed"},
+		{"yaml parser", "yaml", "a:\n  - 1\n - 2\n", 3, 0, "did not find expected key"},
+		{"yaml flow", "yaml", "a: 1\n\nc: {d\n", 3, 0, "did not find expected ',' or '}'"},
+		{"yaml tab", "yaml", "a: 1\nb: 2\n\tc: 3", 3, 0, "found a tab character"},
+		{"yaml block sequence", "yaml", "a: 1\n- b\n", 2, 0, "did not find expected key"},
+		{"yaml late in block", "yaml", "a:\n" + strings.Repeat("  b: 1\n", 100) + "  - c\n", 102, 0, "did not find expected key"},
+		{"yaml missing colon", "yaml", "a: 1\nb: 2\nc: 3\nd\n", 4, 0, "could not find expected ':'"},
+		{"yaml unknown anchor", "yaml", "a: &x 1\nb: [1, *y]\n", 2, 8, "unknown anchor 'y' referenced"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseContext(context.Background(), []byte(tc.doc), tc.format, "doc", Options{})
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("got %v, want *SyntaxError", err)
+			}
+			if syntaxErr.Line != tc.line || syntaxErr.Column != tc.col {
+				t.Errorf("position = %d:%d, want %d:%d (%v)", syntaxErr.Line, syntaxErr.Column, tc.line, tc.col, err)
+			}
+			if !strings.Contains(syntaxErr.Err.Error(), tc.msg) || strings.HasPrefix(syntaxErr.Err.Error(), "yaml:") {
+				t.Errorf("message = %q, want it to contain %q", syntaxErr.Err, tc.msg)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package parsers
+
+import (
+	"context"
+	"mime"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type Parser interface {
+	Parse(data []byte, name string) (map[string]any, error)
+}
+
+// ParserFunc adapts a plain function to Parser.
+type ParserFunc func(data []byte, name string) (map[string]any, error)
+
+func (f ParserFunc) Parse(data []byte, name string) (map[string]any, error) {
+	return f(data, name)
+}
+
+// Spec lists the keys a parser is found by. Names are the values accepted by
+// format override flags; extensions include the leading dot.
+type Spec struct {
+	Names      []string
+	Extensions []string
+	MIMETypes  []string
+}
+
+var (
+	registryMu  sync.RWMutex
+	byName      = map[string]Parser{}
+	byExtension = map[string]Parser{}
+	byMIME      = map[string]Parser{}
+)
+
+// Register adds a parser under every key in spec. It panics when a key is
+// already taken, like formatters.Register.
+func Register(spec Spec, p Parser) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if p == nil {
+		panic("parsers: Register parser is nil")
+	}
+	add := func(m map[string]Parser, key string) {
+		key = strings.ToLower(key)
+		if _, dup := m[key]; dup {
+			panic("parsers: Register called twice for " + key)
+		}
+		m[key] = p
+	}
+	for _, n := range spec.Names {
+		add(byName, n)
+	}
+	for _, ext := range spec.Extensions {
+		add(byExtension, ext)
+	}
+	for _, mt := range spec.MIMETypes {
+		add(byMIME, mt)
+	}
+}
+
+func ByExtension(ext string) (Parser, bool) {
+	return lookup(byExtension, ext)
+}
+
+// ByMIME accepts full media types such as "application/json; charset=utf-8".
+func ByMIME(mediaType string) (Parser, bool) {
+	if mt, _, err := mime.ParseMediaType(mediaType); err == nil {
+		mediaType = mt
+	}
+	return lookup(byMIME, mediaType)
+}
+
+func ByName(name string) (Parser, bool) {
+	return lookup(byName, name)
+}
+
+// Lookup resolves a format given as a name, a MIME type or an extension.
+func Lookup(format string) (Parser, bool) {
+	if p, ok := ByName(format); ok {
+		return p, true
+	}
+	if strings.Contains(format, "/") {
+		return ByMIME(format)
+	}
+	if !strings.HasPrefix(format, ".") {
+		format = "." + format
+	}
+	return ByExtension(format)
+}
+
+// Supported reports whether the file extension maps to a registered parser.
+func Supported(path string) bool {
+	_, ok := ByExtension(filepath.Ext(path))
+	return ok
+}
+
+// Names lists the registered format names, sorted.
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	out := make([]string, 0, len(byName))
+	for n := range byName {
+		out = append(out, n)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func lookup(m map[string]Parser, key string) (Parser, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	p, ok := m[strings.ToLower(key)]
+	return p, ok
+}
+
+func init() {
+	Register(Spec{
+		Names:      []string{"json"},
+		Extensions: []string{".json"},
+		MIMETypes:  []string{"application/json", "text/json"},
+	}, jsonParser{})
+	Register(Spec{
+		Names:      []string{"yaml", "yml"},
+		Extensions: []string{".yaml", ".yml"},
+		MIMETypes:  []string{"application/yaml", "application/x-yaml", "text/yaml", "text/x-yaml"},
+	}, yamlParser{})
+}
+
+type jsonParser struct{}
+
+func (jsonParser) Parse(data []byte, name string) (map[string]any, error) {
+	dst := map[string]any{}
+	if err := parseJSON(dst, data, name); err != nil {
+		return nil, err
+	}
+	return dst, nil
+}
+
+func (jsonParser) FindDuplicates(data []byte) ([]DuplicateKey, error) {
+	return findJSONDuplicates(data)
+}
+
+type yamlParser struct{}
+
+func (p yamlParser) Parse(data []byte, name string) (map[string]any, error) {
+	return p.ParseLimited(context.Background(), data, name, Options{})
+}
+
+func (yamlParser) ParseLimited(ctx context.Context, data []byte, name string, opts Options) (map[string]any, error) {
+	dst := map[string]any{}
+	if err := parseYAML(ctx, dst, data, name, opts); err != nil {
+		return nil, err
+	}
+	return dst, nil
+}
+
+func (yamlParser) FindDuplicates(data []byte) ([]DuplicateKey, error) {
+	return findYAMLDuplicates(data)
+}
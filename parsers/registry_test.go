@@ -0,0 +1,127 @@
+package parsers
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// parseCfg понимает простой формат "key = value" построчно.
+func parseCfg(data []byte, _ string) (map[string]any, error) {
+	out := map[string]any{}
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		k, v, ok := strings.Cut(sc.Text(), "=")
+		if ok {
+			out[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+	}
+	return out, sc.Err()
+}
+
+// registerForTest registers p under spec until t ends, so the test can run
+// again under -count.
+func registerForTest(t *testing.T, spec Spec, p Parser) {
+	t.Helper()
+	Register(spec, p)
+	t.Cleanup(func() {
+		registryMu.Lock()
+		defer registryMu.Unlock()
+		for _, n := range spec.Names {
+			delete(byName, strings.ToLower(n))
+		}
+		for _, ext := range spec.Extensions {
+			delete(byExtension, strings.ToLower(ext))
+		}
+		for _, mt := range spec.MIMETypes {
+			delete(byMIME, strings.ToLower(mt))
+		}
+	})
+}
+
+func TestRegister_CustomFormat(t *testing.T) {
+	registerForTest(t, Spec{
+		Names:      []string{"cfg-test"},
+		Extensions: []string{".cfgtest"},
+		MIMETypes:  []string{"application/x-cfg-test"},
+	}, ParserFunc(parseCfg))
+
+	dir := t.TempDir()
+	p := filepath.Join(dir, "app.CFGTEST")
+	if err := os.WriteFile(p, []byte("a = 1\nb = x\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if !Supported(p) {
+		t.Fatalf("Supported(%q) = false after registration", p)
+	}
+	got, err := ParseFiles(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{"a": "1", "b": "x"}
+	if !reflect.DeepEqual(got[0], want) {
+		t.Fatalf("parsed = %#v, want %#v", got[0], want)
+	}
+
+	for _, format := range []string{"cfg-test", "application/x-cfg-test; charset=utf-8", ".cfgtest", "cfgtest"} {
+		if _, ok := Lookup(format); !ok {
+			t.Fatalf("Lookup(%q) failed", format)
+		}
+	}
+}
+
+func TestLookup_Builtins(t *testing.T) {
+	t.Parallel()
+
+	for _, format := range []string{"json", "YAML", "yml", "application/json", "text/yaml", ".yml"} {
+		if _, ok := Lookup(format); !ok {
+			t.Fatalf("Lookup(%q) failed", format)
+		}
+	}
+	if _, ok := Lookup("toml"); ok {
+		t.Fatalf("Lookup(toml) must fail")
+	}
+}
+
+func TestParseFileAs_Override(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	p := filepath.Join(dir, "values.txt")
+	if err := os.WriteFile(p, []byte("a: 1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := ParseFiles(p)
+	var unsupported *UnsupportedFormatError
+	if !errors.As(err, &unsupported) || unsupported.Format != ".txt" || !unsupported.Extension {
+		t.Fatalf("expected unsupported extension error, got %v", err)
+	}
+	got, err := ParseFileAs(p, "yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, ok := getInt(got["a"]); !ok || n != 1 {
+		t.Fatalf(`"a" = %#v, want 1`, got["a"])
+	}
+	if _, err := ParseFileAs(p, "toml"); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Fatalf("expected unsupported format error, got %v", err)
+	}
+}
+
+func TestRegister_DuplicatePanics(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expected panic on duplicate registration")
+		}
+	}()
+	Register(Spec{Extensions: []string{".json"}}, ParserFunc(parseCfg))
+}
@@ -0,0 +1,162 @@
+package server
+
+import (
+	"bytes"
+	"code/ast"
+	"code/formatters"
+	"code/parsers"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const DefaultMaxBodyBytes = 1 << 20
+
+type Options struct {
+	// MaxBodyBytes caps the POST /diff body; zero means DefaultMaxBodyBytes.
+	MaxBodyBytes int64
+	// Limits applies to each document; nil means parsers.DefaultLimits, while
+	// a zero Limits disables them all.
+	Limits *parsers.Limits
+}
+
+// DiffRequest is the POST /diff body. Left and Right hold the raw documents;
+// LeftFormat and RightFormat name their syntax ("json" or "yaml") and are
+// required.
+type DiffRequest struct {
+	Left        string `json:"left"`
+	LeftFormat  string `json:"leftFormat"`
+	Right       string `json:"right"`
+	RightFormat string `json:"rightFormat"`
+	// Format is the output format, "stylish" by default.
+	Format string `json:"format"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// NewHandler serves POST /diff and GET /healthz.
+func NewHandler(opts Options) http.Handler {
+	if opts.MaxBodyBytes <= 0 {
+		opts.MaxBodyBytes = DefaultMaxBodyBytes
+	}
+	if opts.Limits == nil {
+		limits := parsers.DefaultLimits()
+		opts.Limits = &limits
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = w.Write([]byte("ok\n"))
+	})
+	mux.HandleFunc("POST /diff", func(w http.ResponseWriter, r *http.Request) {
+		handleDiff(w, r, opts)
+	})
+	return mux
+}
+
+func handleDiff(w http.ResponseWriter, r *http.Request, opts Options) {
+	r.Body = http.MaxBytesReader(w, r.Body, opts.MaxBodyBytes)
+
+	var req DiffRequest
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("request body exceeds %d bytes", tooLarge.Limit))
+			return
+		}
+		writeError(w, http.StatusBadRequest, fmt.Errorf("decode request: %w", err))
+		return
+	}
+	if err := checkInputFormats(req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	ctx := r.Context()
+	left, err := parsers.ParseContext(ctx, []byte(req.Left), req.LeftFormat, "left", parsers.Options{Limits: *opts.Limits})
+	if err != nil {
+		writeError(w, parseStatus(err), err)
+		return
+	}
+	right, err := parsers.ParseContext(ctx, []byte(req.Right), req.RightFormat, "right", parsers.Options{Limits: *opts.Limits})
+	if err != nil {
+		writeError(w, parseStatus(err), err)
+		return
+	}
+
+	nodes, err := ast.BuildDiffContext(ctx, left, right)
+	if err != nil {
+		writeError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+	renderOpts := formatters.DefaultOptions()
+	renderOpts.OldLabel, renderOpts.NewLabel = "left", "right"
+	// Rendered into a buffer so a failure can still change the status; ctx
+	// stops the render once the client is gone.
+	var out bytes.Buffer
+	if err := formatters.WriteContext(ctx, &out, req.Format, nodes, renderOpts); err != nil {
+		status := http.StatusBadRequest
+		if ctx.Err() != nil {
+			status = http.StatusServiceUnavailable
+		}
+		writeError(w, status, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType(req.Format))
+	w.Header().Set("X-Gendiff-Changed", strconv.FormatBool(ast.HasChanges(nodes)))
+	_, _ = out.WriteTo(w)
+}
+
+// checkInputFormats rejects a request without leftFormat or rightFormat; the
+// documents carry no extension to guess from.
+func checkInputFormats(req DiffRequest) error {
+	for _, f := range []struct{ field, value string }{
+		{"leftFormat", req.LeftFormat},
+		{"rightFormat", req.RightFormat},
+	} {
+		if f.value == "" {
+			return fmt.Errorf("%s is required, one of: %s", f.field, strings.Join(parsers.Names(), ", "))
+		}
+	}
+	return nil
+}
+
+// parseStatus maps an oversized document to 413 and any other parse failure,
+// limits included, to 422.
+func parseStatus(err error) int {
+	var tooLarge *parsers.SizeLimitError
+	if errors.As(err, &tooLarge) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusUnprocessableEntity
+}
+
+func contentType(format string) string {
+	switch format {
+	case "json", "jsonpatch", "mergepatch":
+		return "application/json"
+	case "html":
+		return "text/html; charset=utf-8"
+	case "markdown":
+		return "text/markdown; charset=utf-8"
+	case "yaml", "yaml-compact":
+		return "application/yaml"
+	default:
+		return "text/plain; charset=utf-8"
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(errorResponse{Error: err.Error()})
+}
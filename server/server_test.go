@@ -0,0 +1,151 @@
+package server
+
+import (
+	"code/parsers"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func post(t *testing.T, h http.Handler, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/diff", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestHealthz(t *testing.T) {
+	rec := httptest.NewRecorder()
+	NewHandler(Options{}).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	if rec.Code != http.StatusOK || rec.Body.String() != "ok\n" {
+		t.Fatalf("healthz = %d %q", rec.Code, rec.Body.String())
+	}
+}
+
+func TestDiff_Plain(t *testing.T) {
+	body := `{"left":"{\"a\":1,\"b\":true}","leftFormat":"json","right":"a: 2\nb: true\n","rightFormat":"yaml","format":"plain"}`
+	rec := post(t, NewHandler(Options{}), body)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body: %s", rec.Code, rec.Body.String())
+	}
+	if got := rec.Body.String(); got != "Property 'a' was updated. From 1 to 2\n" {
+		t.Fatalf("body = %q", got)
+	}
+	if rec.Header().Get("X-Gendiff-Changed") != "true" {
+		t.Fatalf("X-Gendiff-Changed = %q", rec.Header().Get("X-Gendiff-Changed"))
+	}
+}
+
+func TestDiff_JSONTree(t *testing.T) {
+	body := `{"left":"{\"a\":1}","leftFormat":"json","right":"{\"a\":1}","rightFormat":"json","format":"json"}`
+	rec := post(t, NewHandler(Options{}), body)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body: %s", rec.Code, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+	var parsed struct {
+		Diff []map[string]any `json:"diff"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &parsed); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if len(parsed.Diff) != 1 || parsed.Diff[0]["type"] != "unchanged" {
+		t.Fatalf("unexpected tree: %s", rec.Body.String())
+	}
+	if rec.Header().Get("X-Gendiff-Changed") != "false" {
+		t.Fatalf("X-Gendiff-Changed = %q", rec.Header().Get("X-Gendiff-Changed"))
+	}
+}
+
+func TestDiff_Errors(t *testing.T) {
+	h := NewHandler(Options{MaxBodyBytes: 128})
+
+	cases := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"malformed request", `{"left":`, http.StatusBadRequest},
+		{"unknown field", `{"lft":"{}"}`, http.StatusBadRequest},
+		{"bad document", `{"left":"{","leftFormat":"json","rightFormat":"json"}`, http.StatusUnprocessableEntity},
+		{"unknown input format", `{"leftFormat":"toml","rightFormat":"json"}`, http.StatusUnprocessableEntity},
+		{"missing input format", `{"left":"{}","right":"{}","rightFormat":"json"}`, http.StatusBadRequest},
+		{"unknown output format", `{"left":"{}","leftFormat":"json","right":"{}","rightFormat":"json","format":"x"}`, http.StatusBadRequest},
+		{"too large", `{"left":"` + strings.Repeat("x", 200) + `"}`, http.StatusRequestEntityTooLarge},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := post(t, h, tc.body)
+			if rec.Code != tc.want {
+				t.Fatalf("status = %d, want %d; body: %s", rec.Code, tc.want, rec.Body.String())
+			}
+			var e errorResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &e); err != nil || e.Error == "" {
+				t.Fatalf("error body = %q", rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestDiff_MissingInputFormat(t *testing.T) {
+	h := NewHandler(Options{})
+	rec := post(t, h, `{"left":"{}","leftFormat":"json","right":"{}"}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+	var e errorResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &e); err != nil {
+		t.Fatal(err)
+	}
+	if want := "rightFormat is required, one of: json, yaml, yml"; e.Error != want {
+		t.Fatalf("error = %q, want %q", e.Error, want)
+	}
+}
+
+func TestDiff_Limits(t *testing.T) {
+	h := NewHandler(Options{Limits: &parsers.Limits{MaxBytes: 16, MaxDepth: 2}})
+
+	cases := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"document too large", `{"left":"{\"a\":\"` + strings.Repeat("x", 20) + `\"}","leftFormat":"json","right":"{}","rightFormat":"json"}`, http.StatusRequestEntityTooLarge},
+		{"too deep", `{"left":"{\"a\":{\"b\":{}}}","leftFormat":"json","right":"{}","rightFormat":"json"}`, http.StatusUnprocessableEntity},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if rec := post(t, h, tc.body); rec.Code != tc.want {
+				t.Fatalf("status = %d, want %d; body: %s", rec.Code, tc.want, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestDiff_LimitsDisabled(t *testing.T) {
+	deep := strings.Repeat(`{\"a\":`, 70) + "1" + strings.Repeat("}", 70)
+	body := `{"left":"` + deep + `","leftFormat":"json","right":"{}","rightFormat":"json"}`
+
+	if rec := post(t, NewHandler(Options{}), body); rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("default limits: status = %d, want 422; body: %s", rec.Code, rec.Body.String())
+	}
+	if rec := post(t, NewHandler(Options{Limits: &parsers.Limits{}}), body); rec.Code != http.StatusOK {
+		t.Fatalf("no limits: status = %d, want 200; body: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestDiff_MethodNotAllowed(t *testing.T) {
+	rec := httptest.NewRecorder()
+	NewHandler(Options{}).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/diff", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("status = %d, want 405", rec.Code)
+	}
+}
@@ -0,0 +1,418 @@
+// Package stream diffs two JSON documents without loading either into memory.
+//
+// Both inputs are decoded token by token. Object members are matched by key
+// while reading: members that come in the same order on both sides are
+// compared as they arrive and dropped, so an unchanged subtree costs no more
+// than the tokens it is made of. Members whose keys sit at different positions
+// are held as raw JSON until their partner shows up, and are reported as
+// removed or added when the object ends without one. With Options.Sorted the
+// caller promises ascending keys in every object (as written by jq -S); a
+// smaller key is then known to be missing on the other side and nothing is
+// buffered at all.
+//
+// Memory use, beyond a few KiB of read buffer per side:
+//   - the current path, which grows with nesting depth, and the largest key or scalar;
+//   - every changed value, because the result carries it;
+//   - arrays are compared element by element, and only when they differ are
+//     both re-read in full from the io.ReaderAt to report the update;
+//   - without Sorted, members displaced relative to the other side until they
+//     are matched. Same-order inputs buffer nothing; unrelated key orders may
+//     buffer a whole object.
+//
+// The result holds only changes: unchanged members, and objects without
+// changes, are left out of the tree.
+package stream
+
+import (
+	"bufio"
+	"bytes"
+	"code/ast"
+	"code/parsers"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+)
+
+var (
+	ErrNotObject = errors.New("top-level JSON value is not an object")
+	ErrUnsorted  = errors.New("object keys are not sorted")
+)
+
+type Options struct {
+	// LeftName and RightName label the inputs in errors; left and right by default.
+	LeftName  string
+	RightName string
+	// Sorted declares that every object lists its keys in ascending byte order.
+	// A violation is reported as ErrUnsorted rather than producing a wrong diff.
+	Sorted bool
+}
+
+// Diff compares the JSON objects in left and right. Key order of the result
+// matches ast.BuildDiff.
+func Diff(ctx context.Context, left, right io.ReaderAt, opts Options) ([]ast.Node, error) {
+	l, r := newSide(nameOr(opts.LeftName, "left"), left), newSide(nameOr(opts.RightName, "right"), right)
+	for _, s := range []*side{l, r} {
+		tok, err := s.dec.Token()
+		if err != nil {
+			return nil, s.wrap(err)
+		}
+		if tok != json.Delim('{') {
+			return nil, s.wrap(ErrNotObject)
+		}
+	}
+
+	d := differ{ctx: ctx, sorted: opts.Sorted}
+	return d.object(l, r)
+}
+
+func nameOr(name, fallback string) string {
+	if name == "" {
+		return fallback
+	}
+	return name
+}
+
+// side is one input positioned somewhere inside its document. Decoder offsets
+// are relative to the start of r, so a value can be read again from there.
+type side struct {
+	name string
+	r    io.ReaderAt
+	dec  *json.Decoder
+}
+
+func newSide(name string, r io.ReaderAt) *side {
+	dec := json.NewDecoder(io.NewSectionReader(r, 0, math.MaxInt64))
+	dec.UseNumber()
+	return &side{name: name, r: r, dec: dec}
+}
+
+// wrap labels err with the input; malformed JSON becomes a
+// *parsers.SyntaxError at the byte the decoder stopped on. Locating it reads
+// the input again up to there, never decoding it whole.
+func (s *side) wrap(err error) error {
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	if located := parsers.LocateJSONError(s.r, s.name, err); located != nil {
+		return located
+	}
+	return fmt.Errorf("decode %q: %w", s.name, err)
+}
+
+func (s *side) token() (json.Token, error) {
+	tok, err := s.dec.Token()
+	if err != nil {
+		return nil, s.wrap(err)
+	}
+	return tok, nil
+}
+
+// key reads the next member name, or reports the end of the object.
+func (s *side) key() (string, bool, error) {
+	tok, err := s.token()
+	if err != nil {
+		return "", false, err
+	}
+	if tok == json.Delim('}') {
+		return "", false, nil
+	}
+	k, ok := tok.(string)
+	if !ok {
+		return "", false, s.wrap(fmt.Errorf("expected object key, got %v", tok))
+	}
+	return k, true, nil
+}
+
+// value decodes the next value whole.
+func (s *side) value() (any, error) {
+	var v any
+	if err := s.dec.Decode(&v); err != nil {
+		return nil, s.wrap(err)
+	}
+	return parsers.NormalizeNumbers(v), nil
+}
+
+func (s *side) raw() (json.RawMessage, error) {
+	var raw json.RawMessage
+	if err := s.dec.Decode(&raw); err != nil {
+		return nil, s.wrap(err)
+	}
+	return raw, nil
+}
+
+// valueAt decodes the value starting after off, an InputOffset taken before
+// its first token; the ':' or ',' in between is skipped.
+func (s *side) valueAt(off int64) (any, error) {
+	br := bufio.NewReader(io.NewSectionReader(s.r, off, math.MaxInt64-off))
+	for {
+		c, err := br.ReadByte()
+		if err != nil {
+			return nil, s.wrap(err)
+		}
+		if c != ':' && c != ',' && c != ' ' && c != '\t' && c != '\n' && c != '\r' {
+			_ = br.UnreadByte()
+			break
+		}
+	}
+
+	dec := json.NewDecoder(br)
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, s.wrap(err)
+	}
+	return parsers.NormalizeNumbers(v), nil
+}
+
+// skip consumes the rest of a value whose first token was tok, inside depth
+// enclosing containers that also need closing.
+func (s *side) skip(depth int, tok json.Token) error {
+	depth += nesting(tok)
+	for depth > 0 {
+		t, err := s.token()
+		if err != nil {
+			return err
+		}
+		depth += nesting(t)
+	}
+	return nil
+}
+
+func nesting(tok json.Token) int {
+	switch tok {
+	case json.Delim('{'), json.Delim('['):
+		return 1
+	case json.Delim('}'), json.Delim(']'):
+		return -1
+	default:
+		return 0
+	}
+}
+
+type differ struct {
+	ctx    context.Context
+	sorted bool
+}
+
+// object diffs two objects whose opening braces have been read.
+func (d *differ) object(l, r *side) ([]ast.Node, error) {
+	var (
+		out          []ast.Node
+		pendL, pendR = map[string]json.RawMessage{}, map[string]json.RawMessage{}
+		kl, kr       string
+		prevL, prevR string
+		hasL, hasR   bool
+		doneL, doneR bool
+	)
+
+	emit := func(n ast.Node, changed bool) {
+		if changed {
+			out = append(out, n)
+		}
+	}
+
+	for {
+		if err := d.ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		var err error
+		if !hasL && !doneL {
+			if kl, hasL, err = l.key(); err != nil {
+				return nil, err
+			}
+			doneL = !hasL
+			if err := d.checkOrder(l, &prevL, kl, hasL); err != nil {
+				return nil, err
+			}
+		}
+		if !hasR && !doneR {
+			if kr, hasR, err = r.key(); err != nil {
+				return nil, err
+			}
+			doneR = !hasR
+			if err := d.checkOrder(r, &prevR, kr, hasR); err != nil {
+				return nil, err
+			}
+		}
+
+		switch {
+		case hasL && hasR && kl == kr:
+			n, changed, err := d.member(kl, l, r)
+			if err != nil {
+				return nil, err
+			}
+			emit(n, changed)
+			hasL, hasR = false, false
+
+		case hasL && pendR[kl] != nil:
+			n, changed, err := d.member(kl, l, newSide(r.name, bytes.NewReader(pendR[kl])))
+			if err != nil {
+				return nil, err
+			}
+			emit(n, changed)
+			delete(pendR, kl)
+			hasL = false
+
+		case hasR && pendL[kr] != nil:
+			n, changed, err := d.member(kr, newSide(l.name, bytes.NewReader(pendL[kr])), r)
+			if err != nil {
+				return nil, err
+			}
+			emit(n, changed)
+			delete(pendL, kr)
+			hasR = false
+
+		case hasL && (!hasR || d.sorted && kl < kr):
+			v, err := l.value()
+			if err != nil {
+				return nil, err
+			}
+			emit(ast.Node{Key: kl, Action: ast.Removed, OldVal: v}, true)
+			hasL = false
+
+		case hasR && (!hasL || d.sorted):
+			v, err := r.value()
+			if err != nil {
+				return nil, err
+			}
+			emit(ast.Node{Key: kr, Action: ast.Added, NewVal: v}, true)
+			hasR = false
+
+		case hasL && hasR:
+			if pendL[kl], err = l.raw(); err != nil {
+				return nil, err
+			}
+			if pendR[kr], err = r.raw(); err != nil {
+				return nil, err
+			}
+			hasL, hasR = false, false
+
+		default:
+			return finish(out, pendL, pendR)
+		}
+	}
+}
+
+func (d *differ) checkOrder(s *side, prev *string, key string, ok bool) error {
+	if !d.sorted || !ok {
+		return nil
+	}
+	if key < *prev {
+		return s.wrap(fmt.Errorf("%w: %q after %q", ErrUnsorted, key, *prev))
+	}
+	*prev = key
+	return nil
+}
+
+// finish reports members that never found a partner and sorts the changes.
+func finish(out []ast.Node, pendL, pendR map[string]json.RawMessage) ([]ast.Node, error) {
+	for k, raw := range pendL {
+		v, err := decodeRaw(raw)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, ast.Node{Key: k, Action: ast.Removed, OldVal: v})
+	}
+	for k, raw := range pendR {
+		v, err := decodeRaw(raw)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, ast.Node{Key: k, Action: ast.Added, NewVal: v})
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out, nil
+}
+
+func decodeRaw(raw json.RawMessage) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return parsers.NormalizeNumbers(v), nil
+}
+
+// member diffs the values of key on both sides, reporting whether they differ.
+func (d *differ) member(key string, l, r *side) (ast.Node, bool, error) {
+	offL, offR := l.dec.InputOffset(), r.dec.InputOffset()
+
+	tl, err := l.token()
+	if err != nil {
+		return ast.Node{}, false, err
+	}
+	tr, err := r.token()
+	if err != nil {
+		return ast.Node{}, false, err
+	}
+
+	if tl == json.Delim('{') && tr == json.Delim('{') {
+		children, err := d.object(l, r)
+		if err != nil {
+			return ast.Node{}, false, err
+		}
+		return ast.Node{Key: key, Action: ast.Nested, Children: children}, len(children) > 0, nil
+	}
+
+	equal, err := equalRest(l, r, tl, tr)
+	if err != nil || equal {
+		return ast.Node{}, false, err
+	}
+
+	// Token order differs; objects inside arrays may still be equal.
+	oldVal, err := l.valueAt(offL)
+	if err != nil {
+		return ast.Node{}, false, err
+	}
+	newVal, err := r.valueAt(offR)
+	if err != nil {
+		return ast.Node{}, false, err
+	}
+	if ast.Equal(oldVal, newVal) {
+		return ast.Node{}, false, nil
+	}
+	return ast.Node{Key: key, Action: ast.Updated, OldVal: oldVal, NewVal: newVal}, true, nil
+}
+
+// equalRest compares two values token by token, given their first tokens, and
+// consumes both to their end either way.
+func equalRest(l, r *side, tl, tr json.Token) (bool, error) {
+	depth := 0
+	for {
+		if !tokenEqual(tl, tr) {
+			if err := l.skip(depth, tl); err != nil {
+				return false, err
+			}
+			return false, r.skip(depth, tr)
+		}
+
+		depth += nesting(tl)
+		if depth == 0 {
+			return true, nil
+		}
+
+		var err error
+		if tl, err = l.token(); err != nil {
+			return false, err
+		}
+		if tr, err = r.token(); err != nil {
+			return false, err
+		}
+	}
+}
+
+func tokenEqual(a, b json.Token) bool {
+	na, aNum := a.(json.Number)
+	nb, bNum := b.(json.Number)
+	if aNum && bNum {
+		return ast.Equal(parsers.NormalizeNumbers(na), parsers.NormalizeNumbers(nb))
+	}
+	return a == b
+}
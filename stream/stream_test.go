@@ -0,0 +1,221 @@
+package stream
+
+import (
+	"bytes"
+	"code/ast"
+	"code/parsers"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// changesOnly drops what Diff leaves out: unchanged members and nested objects
+// without changes.
+func changesOnly(nodes []ast.Node) []ast.Node {
+	var out []ast.Node
+	for _, n := range nodes {
+		switch n.Action {
+		case ast.Unchanged:
+			continue
+		case ast.Nested:
+			n.Children = changesOnly(n.Children)
+			if len(n.Children) == 0 {
+				continue
+			}
+		}
+		out = append(out, n)
+	}
+	return out
+}
+
+func buildDiff(t *testing.T, left, right string) []ast.Node {
+	t.Helper()
+	a, err := parsers.ParseBytes([]byte(left), "left.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := parsers.ParseBytes([]byte(right), "right.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return changesOnly(ast.BuildDiff(a, b))
+}
+
+func TestDiff_MatchesBuildDiff(t *testing.T) {
+	cases := []struct {
+		name        string
+		left, right string
+	}{
+		{"identical", `{"a":1,"b":{"c":[1,2]}}`, `{"a":1,"b":{"c":[1,2]}}`},
+		{"same order", `{"a":1,"b":"x","c":true}`, `{"a":2,"b":"x","d":null}`},
+		{"reordered keys", `{"a":1,"b":2,"c":3}`, `{"c":3,"b":20,"a":1}`},
+		{"insertion", `{"a":1,"b":{"k":1},"c":3}`, `{"a":1,"x":[1],"b":{"k":2},"c":3}`},
+		{"nested", `{"n":{"a":{"deep":1},"b":2}}`, `{"n":{"b":2,"a":{"deep":"1"}}}`},
+		{"type change", `{"a":{"k":[1,{"z":null}]},"b":[1]}`, `{"a":"str","b":{"k":1}}`},
+		{"array objects reordered", `{"a":[{"x":1,"y":2}]}`, `{"a":[{"y":2,"x":1}]}`},
+		{"array differs late", `{"a":[1,2,3,{"k":"v"}]}`, `{"a":[1,2,3,{"k":"w"}]}`},
+		{"array length", `{"a":[1,2]}`, `{"a":[1,2,3]}`},
+		{"numbers", `{"i":1,"f":1.0,"g":2.50}`, `{"i":1.0,"f":1.00,"g":2.5}`},
+		{"empty", `{}`, `{"a":{}}`},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Diff(context.Background(), strings.NewReader(tc.left), strings.NewReader(tc.right), Options{})
+			if err != nil {
+				t.Fatalf("Diff returned error: %v", err)
+			}
+			if want := buildDiff(t, tc.left, tc.right); !reflect.DeepEqual(got, want) {
+				t.Errorf("got  %#v\nwant %#v", got, want)
+			}
+		})
+	}
+}
+
+func TestDiff_Sorted(t *testing.T) {
+	left := `{"a":1,"b":{"x":1,"y":2},"d":4}`
+	right := `{"a":1,"b":{"x":1,"y":3},"c":3}`
+
+	got, err := Diff(context.Background(), strings.NewReader(left), strings.NewReader(right), Options{Sorted: true})
+	if err != nil {
+		t.Fatalf("Diff returned error: %v", err)
+	}
+	if want := buildDiff(t, left, right); !reflect.DeepEqual(got, want) {
+		t.Errorf("got  %#v\nwant %#v", got, want)
+	}
+
+	_, err = Diff(context.Background(), strings.NewReader(`{"b":1,"a":2}`), strings.NewReader(`{"a":2,"b":1}`), Options{Sorted: true})
+	if !errors.Is(err, ErrUnsorted) {
+		t.Errorf("unsorted input: got %v, want ErrUnsorted", err)
+	}
+}
+
+func TestDiff_Errors(t *testing.T) {
+	ctx := context.Background()
+
+	if _, err := Diff(ctx, strings.NewReader(`[1]`), strings.NewReader(`{}`), Options{}); !errors.Is(err, ErrNotObject) {
+		t.Errorf("array root: got %v, want ErrNotObject", err)
+	}
+	if _, err := Diff(ctx, strings.NewReader(`{"a":1}`), strings.NewReader(`{"a":`), Options{}); err == nil {
+		t.Error("expected an error for truncated input")
+	}
+
+	_, err := Diff(ctx, strings.NewReader("{\n  \"a\": 1,\n  \"b\": }"), strings.NewReader(`{}`), Options{LeftName: "l.json"})
+	var syntaxErr *parsers.SyntaxError
+	if !errors.As(err, &syntaxErr) || syntaxErr.File != "l.json" || syntaxErr.Line != 3 || syntaxErr.Column != 8 {
+		t.Errorf("syntax error: got %v, want l.json:3:8", err)
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := Diff(canceled, strings.NewReader(`{"a":1}`), strings.NewReader(`{"a":1}`), Options{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("canceled context: got %v", err)
+	}
+}
+
+func TestDiff_SyntaxErrorsMatchParser(t *testing.T) {
+	ctx := context.Background()
+	for _, doc := range []string{
+		`{"a":1 "b":2}`,
+		`{"a":{"b":tru}}`,
+		"{\n  \"a\": 1,\n  \"b\": }",
+		`{"a":`,
+		"{\"a\":1\n\n",
+	} {
+		_, want := parsers.ParseContext(ctx, []byte(doc), "json", "x.json", parsers.Options{})
+		_, got := Diff(ctx, strings.NewReader(doc), strings.NewReader(`{"a":1}`), Options{LeftName: "x.json"})
+		if got == nil || want == nil || got.Error() != want.Error() {
+			t.Errorf("%q: stream reports %v, parser %v", doc, got, want)
+		}
+	}
+}
+
+// The token decoder blames the comma before a closing bracket, where a
+// whole-document parse blames the bracket.
+func TestDiff_TrailingComma(t *testing.T) {
+	ctx := context.Background()
+	for doc, want := range map[string]string{
+		`{"a":1,}`:   "x.json:1:7: invalid JSON: invalid character ',' looking for beginning of value",
+		`{"a":[1,]}`: "x.json:1:8: invalid JSON: invalid character ',' looking for beginning of value",
+	} {
+		_, err := Diff(ctx, strings.NewReader(doc), strings.NewReader(`{"a":1}`), Options{LeftName: "x.json"})
+		if err == nil || err.Error() != want {
+			t.Errorf("%q: got %v, want %s", doc, err, want)
+		}
+	}
+}
+
+// dataset builds a JSON object of n records, changing every step-th one.
+func dataset(n, step int, changed bool) []byte {
+	var b bytes.Buffer
+	b.WriteString("{")
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		status := "active"
+		if changed && i%step == 0 {
+			status = "archived"
+		}
+		rec, _ := json.Marshal(map[string]any{
+			"id":     i,
+			"name":   fmt.Sprintf("record %d", i),
+			"status": status,
+			"tags":   []string{"a", "b", "c"},
+			"attrs":  map[string]any{"weight": i * 3, "score": float64(i) / 7},
+		})
+		fmt.Fprintf(&b, "%q:%s", fmt.Sprintf("r%07d", i), rec)
+	}
+	b.WriteString("}")
+	return b.Bytes()
+}
+
+// The stream benchmark allocates per token and keeps only the changes; the
+// baseline holds both parsed documents and the full node tree at once. Compare
+// B/op: go test ./stream -bench . -benchmem
+func BenchmarkDiff(b *testing.B) {
+	left, right := dataset(20000, 100, false), dataset(20000, 100, true)
+	b.SetBytes(int64(len(left) + len(right)))
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		nodes, err := Diff(context.Background(), bytes.NewReader(left), bytes.NewReader(right), Options{})
+		if err != nil || len(nodes) != 200 {
+			b.Fatalf("Diff = %d nodes, %v", len(nodes), err)
+		}
+	}
+}
+
+func BenchmarkDiffSorted(b *testing.B) {
+	left, right := dataset(20000, 100, false), dataset(20000, 100, true)
+	b.SetBytes(int64(len(left) + len(right)))
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := Diff(context.Background(), bytes.NewReader(left), bytes.NewReader(right), Options{Sorted: true}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkBuildDiff(b *testing.B) {
+	left, right := dataset(20000, 100, false), dataset(20000, 100, true)
+	b.SetBytes(int64(len(left) + len(right)))
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		a, err := parsers.ParseBytes(left, "left.json")
+		if err != nil {
+			b.Fatal(err)
+		}
+		c, err := parsers.ParseBytes(right, "right.json")
+		if err != nil {
+			b.Fatal(err)
+		}
+		ast.BuildDiff(a, c)
+	}
+}
@@ -0,0 +1,202 @@
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+const (
+	DefaultInterval = 300 * time.Millisecond
+	DefaultDebounce = 200 * time.Millisecond
+)
+
+type Options struct {
+	// Interval is how often the files are polled.
+	Interval time.Duration
+	// Debounce is how long the files must stay unchanged before onChange runs,
+	// so an editor's multi-step save triggers one re-render.
+	Debounce time.Duration
+	// Poll skips file system events and always polls, for file systems that
+	// do not report changes, such as most network mounts.
+	Poll bool
+}
+
+// fileState is what a poll compares; a missing file is a state of its own.
+type fileState struct {
+	exists  bool
+	size    int64
+	modTime time.Time
+}
+
+// clock is the time source of the watch loops, replaced in tests.
+type clock interface {
+	After(d time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// Watch waits for changes to paths until ctx is done and calls onChange after
+// a settled change. It listens for file system events and falls back to
+// polling when the platform cannot deliver them. It returns ctx.Err() when
+// stopped.
+func Watch(ctx context.Context, paths []string, opts Options, onChange func()) error {
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultInterval
+	}
+	if opts.Debounce < 0 {
+		opts.Debounce = 0
+	}
+
+	if !opts.Poll {
+		w, err := newNotifier(paths)
+		if err == nil {
+			defer w.Close()
+			return watchEvents(ctx, realClock{}, paths, opts, w.wake(ctx, paths), onChange)
+		}
+	}
+	return poll(ctx, realClock{}, paths, opts, onChange)
+}
+
+// poll compares the files every Interval and calls onChange once a change has
+// stood for Debounce.
+func poll(ctx context.Context, clk clock, paths []string, opts Options, onChange func()) error {
+	last := snapshot(paths)
+	var dirtySince time.Time
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case now := <-clk.After(opts.Interval):
+			cur := snapshot(paths)
+			if !equal(cur, last) {
+				last = cur
+				dirtySince = now
+				continue
+			}
+			if !dirtySince.IsZero() && now.Sub(dirtySince) >= opts.Debounce {
+				dirtySince = time.Time{}
+				onChange()
+			}
+		}
+	}
+}
+
+// watchEvents compares the files Debounce after the last wake-up and calls
+// onChange when they differ from what it saw before.
+func watchEvents(ctx context.Context, clk clock, paths []string, opts Options, wake <-chan struct{}, onChange func()) error {
+	last := snapshot(paths)
+	var settled <-chan time.Time
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-wake:
+			settled = clk.After(opts.Debounce)
+		case <-settled:
+			settled = nil
+			if cur := snapshot(paths); !equal(cur, last) {
+				last = cur
+				onChange()
+			}
+		}
+	}
+}
+
+// notifier watches the directories holding the files rather than the files,
+// so editors that save by renaming a new file into place keep being seen.
+type notifier struct {
+	*fsnotify.Watcher
+}
+
+func newNotifier(paths []string) (*notifier, error) {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	added := map[string]bool{}
+	for _, p := range paths {
+		dir := filepath.Dir(absPath(p))
+		if added[dir] {
+			continue
+		}
+		if err := w.Add(dir); err != nil {
+			w.Close()
+			return nil, err
+		}
+		added[dir] = true
+	}
+	return &notifier{w}, nil
+}
+
+// wake signals every event on one of paths. Watcher errors, such as a dropped
+// event queue, signal too, so the files are compared again.
+func (n *notifier) wake(ctx context.Context, paths []string) <-chan struct{} {
+	names := map[string]bool{}
+	for _, p := range paths {
+		names[absPath(p)] = true
+	}
+	out := make(chan struct{}, 1)
+	signal := func() {
+		select {
+		case out <- struct{}{}:
+		default:
+		}
+	}
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case ev, ok := <-n.Events:
+				if !ok {
+					return
+				}
+				if names[filepath.Clean(ev.Name)] {
+					signal()
+				}
+			case _, ok := <-n.Errors:
+				if !ok {
+					return
+				}
+				signal()
+			}
+		}
+	}()
+	return out
+}
+
+func absPath(p string) string {
+	if abs, err := filepath.Abs(p); err == nil {
+		return abs
+	}
+	return filepath.Clean(p)
+}
+
+func snapshot(paths []string) []fileState {
+	out := make([]fileState, len(paths))
+	for i, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			continue
+		}
+		out[i] = fileState{exists: true, size: info.Size(), modTime: info.ModTime()}
+	}
+	return out
+}
+
+func equal(a, b []fileState) bool {
+	for i := range a {
+		if a[i].exists != b[i].exists || a[i].size != b[i].size || !a[i].modTime.Equal(b[i].modTime) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,212 @@
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock fires timers only when the test advances it. Every After call is
+// reported on waits, which tells the test the loop is idle again.
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []fakeTimer
+	waits  chan struct{}
+}
+
+type fakeTimer struct {
+	at time.Time
+	ch chan time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Unix(0, 0), waits: make(chan struct{}, 100)}
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	ch := make(chan time.Time, 1)
+	c.timers = append(c.timers, fakeTimer{at: c.now.Add(d), ch: ch})
+	c.mu.Unlock()
+	c.waits <- struct{}{}
+	return ch
+}
+
+// advance moves the clock and fires the timers that came due.
+func (c *fakeClock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	kept := c.timers[:0]
+	for _, t := range c.timers {
+		if t.at.After(c.now) {
+			kept = append(kept, t)
+			continue
+		}
+		t.ch <- t.at
+	}
+	c.timers = kept
+}
+
+// idle waits until the loop has armed its next timer.
+func (c *fakeClock) idle(t *testing.T) {
+	t.Helper()
+	select {
+	case <-c.waits:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("watch loop did not wait for the clock")
+	}
+}
+
+func writeFile(t *testing.T, p, data string) {
+	t.Helper()
+	if err := os.WriteFile(p, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPoll_DebouncedChange(t *testing.T) {
+	dir := t.TempDir()
+	p := filepath.Join(dir, "a.json")
+	writeFile(t, p, `{}`)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	clk := newFakeClock()
+	calls := 0
+	go poll(ctx, clk, []string{p, filepath.Join(dir, "missing.json")}, Options{
+		Interval: 10 * time.Millisecond,
+		Debounce: 40 * time.Millisecond,
+	}, func() { calls++ })
+	clk.idle(t)
+
+	// a burst of writes, like an editor saving in several steps
+	for i := range 3 {
+		writeFile(t, p, `{"a":`+strings.Repeat("1", i+1)+`}`)
+		clk.advance(10 * time.Millisecond)
+		clk.idle(t)
+	}
+	for range 3 {
+		clk.advance(10 * time.Millisecond)
+		clk.idle(t)
+	}
+	if calls != 0 {
+		t.Fatalf("onChange called %d times before the change settled", calls)
+	}
+	clk.advance(10 * time.Millisecond)
+	clk.idle(t)
+	if calls != 1 {
+		t.Fatalf("onChange called %d times once settled, want 1", calls)
+	}
+	for range 10 {
+		clk.advance(10 * time.Millisecond)
+		clk.idle(t)
+	}
+	if calls != 1 {
+		t.Fatalf("onChange called %d times without a new change, want 1", calls)
+	}
+}
+
+func TestWatchEvents_DebouncedChange(t *testing.T) {
+	dir := t.TempDir()
+	p := filepath.Join(dir, "a.json")
+	writeFile(t, p, `{}`)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	clk := newFakeClock()
+	wake := make(chan struct{})
+	fired := make(chan struct{}, 10)
+	go watchEvents(ctx, clk, []string{p}, Options{Debounce: 40 * time.Millisecond}, wake, func() {
+		fired <- struct{}{}
+	})
+
+	// each event of a burst pushes the comparison back
+	for i := range 3 {
+		writeFile(t, p, `{"a":`+strings.Repeat("1", i+1)+`}`)
+		wake <- struct{}{}
+		clk.idle(t)
+		clk.advance(30 * time.Millisecond)
+	}
+	if n := len(fired); n != 0 {
+		t.Fatalf("onChange called %d times during the burst", n)
+	}
+	clk.advance(10 * time.Millisecond)
+	waitFired(t, fired)
+
+	// an event that leaves the file as it was is not a change
+	wake <- struct{}{}
+	clk.idle(t)
+	clk.advance(40 * time.Millisecond)
+	wake <- struct{}{}
+	clk.idle(t)
+	writeFile(t, p, `{"b":1}`)
+	clk.advance(40 * time.Millisecond)
+	waitFired(t, fired)
+	if n := len(fired); n != 0 {
+		t.Fatalf("onChange called %d extra times for an unchanged file", n)
+	}
+}
+
+func waitFired(t *testing.T, fired <-chan struct{}) {
+	t.Helper()
+	select {
+	case <-fired:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("onChange was not called")
+	}
+}
+
+func TestWatch_Events(t *testing.T) {
+	dir := t.TempDir()
+	p := filepath.Join(dir, "a.json")
+	writeFile(t, p, `{}`)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	fired := make(chan struct{}, 10)
+	started := make(chan struct{})
+	go func() {
+		close(started)
+		_ = Watch(ctx, []string{p}, Options{Interval: time.Hour, Debounce: 10 * time.Millisecond}, func() {
+			fired <- struct{}{}
+		})
+	}()
+	<-started
+
+	// An hour-long poll interval means only events can deliver the change;
+	// keep saving until the watcher, set up concurrently, reports one.
+	for i := 0; ; i++ {
+		writeFile(t, p, `{"a":`+strings.Repeat("1", i+1)+`}`)
+		select {
+		case <-fired:
+			return
+		case <-time.After(200 * time.Millisecond):
+		case <-ctx.Done():
+			t.Fatalf("onChange was not called")
+		}
+	}
+}
+
+func TestWatch_StopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := Watch(ctx, nil, Options{}, func() {})
+	if err == nil {
+		t.Fatalf("expected context error, got nil")
+	}
+}
+
+func TestEqual_MissingFile(t *testing.T) {
+	present := []fileState{{exists: true, size: 1}}
+	missing := []fileState{{}}
+	if equal(present, missing) {
+		t.Fatalf("deleted file must count as a change")
+	}
+}